@@ -20,11 +20,11 @@ package tgbot
 // add feature: "Take control"
 // (emulate user interaction with bot for some user)
 
-// add feature: Make tRegistrator thread-safe
+// [DONE] add feature: Make tRegistrator thread-safe
 
 // tReceiver should decode ikb data, tEvent.Data should be tViewID
 
-// add AutoRegister param to tViewIDConverter
+// [DONE] add AutoRegister param to tViewIDConverter
 // (auto register view id while trying encode if view id isn't registered)
 
 // rename all "parent" to more readable names
@@ -0,0 +1,324 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package button
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/qioalice/devola/core/chat"
+	"github.com/qioalice/devola/core/errors"
+	"github.com/qioalice/devola/core/event"
+	"github.com/qioalice/devola/core/session"
+	"github.com/qioalice/devola/core/view"
+)
+
+// Data is the decoded callback data of inline button.
+type Data struct {
+
+	// The view the button has been sent in.
+	ViewID view.IDEnc `json:"view_id"`
+
+	// The session the button has been sent in.
+	SessionID session.SessionID `json:"session_id"`
+
+	// The action of button (the event data for routing).
+	Action event.Data `json:"action"`
+
+	// Small additional arguments of button.
+	Args []string `json:"args,omitempty"`
+}
+
+// Codec is the encoder of Data to compact callback data of inline buttons
+// and vice-versa.
+//
+// Callback data is signed by truncated HMAC-SHA256 of secret key
+// bound to the chat the button is sent to. So, the buttons can't be forged
+// by client or sent to another chat, and the buttons of old sessions
+// are detected by Decode method.
+//
+// Codec is safe for concurrent use.
+type Codec struct {
+
+	// The secret key of HMAC.
+	key []byte
+
+	// The size of truncated HMAC in bytes (see ParamMACSize).
+	macSize int
+
+	// The max size of encoded callback data in bytes (see ParamMaxSize).
+	maxSize int
+
+	// The storage of args that don't fit callback data and how long
+	// they are kept there (see ParamStore). Nil if there is no storage.
+	store    Store
+	storeTTL time.Duration
+}
+
+// Predefined constants.
+const (
+
+	// The versions of callback data format (the first byte of callback data):
+	// args are in callback data or only their key in Store is.
+	cVersion       byte = 1
+	cVersionStored byte = 2
+
+	// Default max size of encoded callback data (the limit of Telegram).
+	cMaxSizeDefault = 64
+
+	// Default and min sizes of truncated HMAC.
+	cMACSizeDefault = 8
+	cMACSizeMin     = 4
+
+	// Min size of HMAC secret key.
+	// Shorter keys make signatures of callback data easy to forge.
+	cKeySizeMin = 16
+)
+
+// encoding is the encoding of binary callback data to the text.
+var encoding = base64.RawURLEncoding
+
+// Encode returns the callback data of d for the button that is sent
+// to the chat idt.
+//
+// If the callback data doesn't fit the backend's limit (see ParamMaxSize)
+// and there is Store (see ParamStore), args are saved to the Store
+// and only their key is put to callback data.
+//
+// Returns ECTooLong if the callback data doesn't fit the limit anyway
+// or ECStoreFailed if args can't be saved to the Store.
+func (c *Codec) Encode(idt chat.IDT, d *Data) (string, errors.Code) {
+
+	s, fits := c.encode(idt, cVersion, d, d.Args)
+	if fits {
+		return s, errors.ECOK
+	}
+
+	if c.store == nil || len(d.Args) == 0 {
+		return "", ECTooLong
+	}
+
+	key, err := makeKey()
+	if err != nil {
+		return "", ECStoreFailed
+	}
+
+	// Check before saving, so nothing is saved if action is too long.
+	if s, fits = c.encode(idt, cVersionStored, d, []string{key}); !fits {
+		return "", ECTooLong
+	}
+
+	if err := c.store.Save(key, d.Args, c.storeTTL); err != nil {
+		return "", ECStoreFailed
+	}
+
+	return s, errors.ECOK
+}
+
+// encode returns the callback data of d with args of format version
+// and reports whether it fits the backend's limit.
+func (c *Codec) encode(idt chat.IDT, version byte, d *Data, args []string) (string, bool) {
+
+	buf := []byte{version}
+	buf = binary.AppendUvarint(buf, uint64(d.ViewID))
+	buf = binary.AppendUvarint(buf, uint64(d.SessionID))
+	buf = appendString(buf, string(d.Action))
+
+	buf = binary.AppendUvarint(buf, uint64(len(args)))
+	for _, arg := range args {
+		buf = appendString(buf, arg)
+	}
+
+	buf = append(buf, c.sign(idt, buf)...)
+
+	if encoding.EncodedLen(len(buf)) > c.maxSize {
+		return "", false
+	}
+
+	return encoding.EncodeToString(buf), true
+}
+
+// Decode returns the Data of callback data s of the button that is pressed
+// in the chat idt which current session is sessID.
+//
+// Returns ECBadFormat if s is not made by Codec, ECBadSignature
+// if s is forged or made for another chat, or ECStale (with decoded Data)
+// if s is made for another session. The session is not checked
+// if sessID is session.CSessionIDNil.
+//
+// If args has been saved to Store (see Encode), they are loaded from it.
+// Returns ECExpired (with decoded Data without args) if they are expired
+// or ECStoreFailed if they can't be loaded.
+func (c *Codec) Decode(idt chat.IDT, sessID session.SessionID, s string) (*Data, errors.Code) {
+
+	buf, err := encoding.DecodeString(s)
+	if err != nil || len(buf) <= c.macSize || buf[0] != cVersion && buf[0] != cVersionStored {
+		return nil, ECBadFormat
+	}
+
+	payload, mac := buf[:len(buf)-c.macSize], buf[len(buf)-c.macSize:]
+	if !hmac.Equal(mac, c.sign(idt, payload)) {
+		return nil, ECBadSignature
+	}
+
+	d, ok := parse(payload[1:])
+	if !ok {
+		return nil, ECBadFormat
+	}
+
+	if sessID != session.CSessionIDNil && d.SessionID != sessID {
+		return d, ECStale
+	}
+
+	if buf[0] == cVersionStored {
+		return c.load(d)
+	}
+
+	return d, errors.ECOK
+}
+
+// load replaces the key of args of d by args loaded from Store.
+func (c *Codec) load(d *Data) (*Data, errors.Code) {
+
+	if len(d.Args) != 1 {
+		return nil, ECBadFormat
+	}
+
+	key := d.Args[0]
+	d.Args = nil
+
+	if c.store == nil {
+		return d, ECStoreFailed
+	}
+
+	args, found, err := c.store.Load(key)
+	switch {
+	case err != nil:
+		return d, ECStoreFailed
+	case !found:
+		return d, ECExpired
+	}
+
+	d.Args = args
+	return d, errors.ECOK
+}
+
+// Space returns how many bytes (in binary form) are left for the payload
+// of button: View ID, session ID, action and args (as varints and strings
+// prefixed by varint lengths).
+func (c *Codec) Space() int {
+	return c.maxSize*3/4 - 1 - c.macSize
+}
+
+// sign returns truncated HMAC of payload bound to the chat idt.
+func (c *Codec) sign(idt chat.IDT, payload []byte) []byte {
+
+	h := hmac.New(sha256.New, c.key)
+
+	var chatID [8]byte
+	binary.BigEndian.PutUint64(chatID[:], uint64(idt))
+	_, _ = h.Write(chatID[:])
+	_, _ = h.Write(payload)
+
+	return h.Sum(nil)[:c.macSize]
+}
+
+// appendString appends the length of s and s to buf.
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// parse returns the Data decoded from buf (without version byte and HMAC).
+func parse(buf []byte) (*Data, bool) {
+
+	d := new(Data)
+	var ok bool
+
+	var n uint64
+	if n, buf, ok = readUvarint(buf); !ok || n > uint64(^view.IDEnc(0)) {
+		return nil, false
+	}
+	d.ViewID = view.IDEnc(n)
+
+	if n, buf, ok = readUvarint(buf); !ok || n > uint64(^session.SessionID(0)) {
+		return nil, false
+	}
+	d.SessionID = session.SessionID(n)
+
+	var action string
+	if action, buf, ok = readString(buf); !ok {
+		return nil, false
+	}
+	d.Action = event.Data(action)
+
+	if n, buf, ok = readUvarint(buf); !ok || n > uint64(len(buf)) {
+		return nil, false
+	}
+
+	for i := uint64(0); i < n; i++ {
+		var arg string
+		if arg, buf, ok = readString(buf); !ok {
+			return nil, false
+		}
+		d.Args = append(d.Args, arg)
+	}
+
+	return d, len(buf) == 0
+}
+
+// readUvarint reads the unsigned varint from buf
+// and returns it and the rest of buf.
+func readUvarint(buf []byte) (uint64, []byte, bool) {
+	n, size := binary.Uvarint(buf)
+	if size <= 0 {
+		return 0, nil, false
+	}
+	return n, buf[size:], true
+}
+
+// readString reads the string with its length from buf
+// and returns it and the rest of buf.
+func readString(buf []byte) (string, []byte, bool) {
+	n, buf, ok := readUvarint(buf)
+	if !ok || n > uint64(len(buf)) {
+		return "", nil, false
+	}
+	return string(buf[:n]), buf[n:], true
+}
+
+// MakeCodec is the Codec constructor.
+//
+// Saves the secret key of HMAC (it must be kept in secret and be the same
+// across restarts, otherwise all sent buttons become invalid)
+// and applies all params (see ParamMaxSize, ParamMACSize).
+//
+// Panics if key is shorter than 16 bytes: it's a configuration error
+// that must not be silently turned into forgeable buttons.
+func MakeCodec(key []byte, params ...interface{}) *Codec {
+
+	if len(key) < cKeySizeMin {
+		panic(fmt.Sprintf("button: HMAC key must be at least %d bytes, got %d", cKeySizeMin, len(key)))
+	}
+
+	c := &Codec{
+		key:     append([]byte(nil), key...),
+		macSize: cMACSizeDefault,
+		maxSize: cMaxSizeDefault,
+	}
+
+	for _, p := range params {
+		if p, ok := p.(param); ok && p != nil {
+			p(c)
+		}
+	}
+
+	return c
+}
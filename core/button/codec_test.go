@@ -0,0 +1,60 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package button
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/qioalice/devola/core/chat"
+	"github.com/qioalice/devola/core/errors"
+)
+
+var testKey = []byte("0123456789abcdef")
+
+func TestCodecRoundTrip(t *testing.T) {
+
+	c := MakeCodec(testKey)
+	idt := chat.NewIDT(-1001234567890, 2)
+
+	d := &Data{ViewID: 3, SessionID: 7, Action: "buy", Args: []string{"42", "red"}}
+
+	s, code := c.Encode(idt, d)
+	if code != errors.ECOK {
+		t.Fatalf("Encode: unexpected code %v", code)
+	}
+
+	got, code := c.Decode(idt, d.SessionID, s)
+	if code != errors.ECOK {
+		t.Fatalf("Decode: unexpected code %v", code)
+	}
+	if !reflect.DeepEqual(got, d) {
+		t.Fatalf("Decode = %+v, want %+v", got, d)
+	}
+
+	if _, code := c.Decode(chat.NewIDT(42, 1), d.SessionID, s); code != ECBadSignature {
+		t.Fatalf("Decode for another chat: got code %v, want ECBadSignature", code)
+	}
+}
+
+func TestMakeCodecShortKey(t *testing.T) {
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("MakeCodec with 15 bytes key: no panic")
+		}
+	}()
+
+	MakeCodec(testKey[:15])
+}
+
+func TestParamMaxSizeNotPositive(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		if c := MakeCodec(testKey, ParamMaxSize(size)); c.maxSize != cMaxSizeDefault {
+			t.Errorf("ParamMaxSize(%d): max size is %d, want %d", size, c.maxSize, cMaxSizeDefault)
+		}
+	}
+}
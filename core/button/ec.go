@@ -0,0 +1,52 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package button
+
+import (
+	"github.com/qioalice/devola/core/errors"
+)
+
+// Predefined error codes of all callback data operations.
+// These codes may be returned from Codec methods.
+const (
+
+	// Too long callback data error.
+	// Returned:
+	// - From Encode method if encoded callback data doesn't fit
+	//   the backend's limit (see ParamMaxSize).
+	ECTooLong errors.Code = 31
+
+	// Bad format error.
+	// Returned:
+	// - From Decode method if callback data is not made by Codec
+	//   or it is broken.
+	ECBadFormat errors.Code = 32
+
+	// Bad signature error.
+	// Returned:
+	// - From Decode method if the signature of callback data is wrong:
+	//   callback data is forged or made for another chat or by another key.
+	ECBadSignature errors.Code = 33
+
+	// Stale callback data error.
+	// Returned:
+	// - From Decode method if callback data is made for another session
+	//   of the same chat (button of old message is pressed).
+	ECStale errors.Code = 34
+
+	// Expired button error.
+	// Returned:
+	// - From Decode method if args of button has been saved to Store
+	//   but they are expired (or removed) there.
+	ECExpired errors.Code = 35
+
+	// Store failure error.
+	// Returned:
+	// - From Encode method if args can't be saved to Store.
+	// - From Decode method if args can't be loaded from Store
+	//   (or there is no Store).
+	ECStoreFailed errors.Code = 36
+)
@@ -0,0 +1,45 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package button
+
+import (
+	"crypto/sha256"
+	"time"
+)
+
+// param is an alias to function that takes a Codec object and changes
+// its behaviour.
+// It uses as parameters for Codec constructor.
+type param func(c *Codec)
+
+// ParamMaxSize sets the max size of encoded callback data in bytes
+// (64 by default, the limit of Telegram). Not positive size is ignored.
+func ParamMaxSize(size int) param {
+	return func(c *Codec) {
+		if size > 0 {
+			c.maxSize = size
+		}
+	}
+}
+
+// ParamMACSize sets the size of truncated signature (HMAC-SHA256)
+// in bytes (8 by default). It can't be less than 4 or more than 32.
+// The longer the signature, the harder to forge it,
+// but the less space is left for args.
+func ParamMACSize(size int) param {
+	return func(c *Codec) {
+		if size >= cMACSizeMin && size <= sha256.Size {
+			c.macSize = size
+		}
+	}
+}
+
+// ParamStore sets the Store args of buttons are saved to for ttl
+// if they don't fit the callback data (see Codec.Encode).
+// Buttons which args are expired are rejected with ECExpired.
+func ParamStore(store Store, ttl time.Duration) param {
+	return func(c *Codec) { c.store, c.storeTTL = store, ttl }
+}
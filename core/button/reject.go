@@ -0,0 +1,57 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package button
+
+import (
+	"github.com/qioalice/devola/core/errors"
+	"github.com/qioalice/devola/core/event"
+)
+
+// Reasons of rejection of inline buttons.
+// They are the event data of rejected events (see event.CTypeRejected),
+// so the handlers for them can be registered as for any other event:
+//
+//	r.Complex(event.CTypeRejected, string(button.CRejectStale), nil).Handler(h)
+const (
+	CRejectBadFormat    event.Data = "bad_format"
+	CRejectBadSignature event.Data = "bad_signature"
+	CRejectStale        event.Data = "stale"
+	CRejectExpired      event.Data = "expired"
+	CRejectUnavailable  event.Data = "unavailable"
+)
+
+// Reject turns e to the rejected event (see event.CTypeRejected)
+// with the reason of code returned by Codec.Decode.
+// Does nothing if code is errors.ECOK.
+//
+// Backend must call it instead of filling e by callback data
+// if Codec.Decode is failed, and then route e as usual.
+func Reject(e *event.Event, code errors.Code) {
+
+	var reason event.Data
+	switch code {
+	case errors.ECOK:
+		return
+	case ECBadSignature:
+		reason = CRejectBadSignature
+	case ECStale:
+		reason = CRejectStale
+	case ECExpired:
+		reason = CRejectExpired
+	case ECStoreFailed:
+		reason = CRejectUnavailable
+	default:
+		reason = CRejectBadFormat
+	}
+
+	e.Type, e.Data, e.Captures, e.Args = event.CTypeRejected, reason, nil, nil
+}
+
+// Apply fills e by d: the action becomes event data and args become event args.
+// The type of e (inline button event of backend) is not changed.
+func (d *Data) Apply(e *event.Event) {
+	e.Data, e.Args = d.Action, d.Args
+}
@@ -0,0 +1,228 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package button
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Store is the server-side storage of args of buttons that don't fit
+// the backend's callback data limit (see ParamStore).
+//
+// Codec saves all args under a short random key and puts only that key
+// to the callback data. Args are loaded back by Codec.Decode.
+type Store interface {
+
+	// Save saves args under key for ttl.
+	Save(key string, args []string, ttl time.Duration) error
+
+	// Load returns args saved under key or nil and false
+	// if there is no such key or args are expired.
+	Load(key string) ([]string, bool, error)
+}
+
+// MemoryStore is the in-memory Store.
+// Expired args are removed lazily and by Sweep method.
+//
+// MemoryStore is safe for concurrent use.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]storeEntry
+
+	// Saves since the last sweep.
+	saves int
+}
+
+// FileStore is the Store that keeps args in files (one file per key)
+// of some directory, so they survive restarts.
+// Expired files are removed lazily and by Sweep method.
+//
+// FileStore is safe for concurrent use.
+type FileStore struct {
+	dir string
+}
+
+// storeEntry is one saved args with their expiration time.
+type storeEntry struct {
+	Args     []string `json:"args"`
+	ExpireAt int64    `json:"expire_at"`
+}
+
+// Predefined constants.
+const (
+
+	// The size of random key in bytes (before encoding).
+	cStoreKeySize = 9
+
+	// MemoryStore removes expired args each cStoreSweepEvery saves.
+	cStoreSweepEvery = 1024
+)
+
+// isExpiredAt reports whether e is expired at the stamp (unix nano).
+func (e *storeEntry) isExpiredAt(stamp int64) bool {
+	return e.ExpireAt <= stamp
+}
+
+// Save implements Store.
+func (s *MemoryStore) Save(key string, args []string, ttl time.Duration) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.saves++; s.saves >= cStoreSweepEvery {
+		s.sweep()
+	}
+
+	s.entries[key] = storeEntry{append([]string(nil), args...), time.Now().Add(ttl).UnixNano()}
+	return nil
+}
+
+// Load implements Store.
+func (s *MemoryStore) Load(key string) ([]string, bool, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, found := s.entries[key]
+	if !found {
+		return nil, false, nil
+	}
+
+	if e.isExpiredAt(time.Now().UnixNano()) {
+		delete(s.entries, key)
+		return nil, false, nil
+	}
+
+	return append([]string(nil), e.Args...), true, nil
+}
+
+// Sweep removes all expired args.
+func (s *MemoryStore) Sweep() {
+	s.mu.Lock()
+	s.sweep()
+	s.mu.Unlock()
+}
+
+// sweep is the Sweep's core. s.mu must be locked.
+func (s *MemoryStore) sweep() {
+
+	now := time.Now().UnixNano()
+	for key, e := range s.entries {
+		if e.isExpiredAt(now) {
+			delete(s.entries, key)
+		}
+	}
+
+	s.saves = 0
+}
+
+// Save implements Store.
+func (s *FileStore) Save(key string, args []string, ttl time.Duration) error {
+
+	data, err := json.Marshal(storeEntry{args, time.Now().Add(ttl).UnixNano()})
+	if err != nil {
+		return err
+	}
+
+	// Write to temporary file first, so Load never reads a half written file.
+	tmp, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Close()
+	} else {
+		_ = tmp.Close()
+	}
+
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path(key))
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+
+	return err
+}
+
+// Load implements Store.
+func (s *FileStore) Load(key string) ([]string, bool, error) {
+
+	data, err := os.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var e storeEntry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, false, err
+	}
+
+	if e.isExpiredAt(time.Now().UnixNano()) {
+		_ = os.Remove(s.path(key))
+		return nil, false, nil
+	}
+
+	return e.Args, true, nil
+}
+
+// Sweep removes files of all expired args.
+func (s *FileStore) Sweep() error {
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Name()[0] == '.' {
+			continue
+		}
+		// Load removes expired file.
+		_, _, _ = s.Load(entry.Name())
+	}
+
+	return nil
+}
+
+// path returns the path of file of key.
+// Keys are made by Codec (see makeKey), so they are safe file names.
+func (s *FileStore) path(key string) string {
+	return filepath.Join(s.dir, filepath.Base(key))
+}
+
+// makeKey returns a new random key for Store.
+func makeKey() (string, error) {
+	var key [cStoreKeySize]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		return "", err
+	}
+	return encoding.EncodeToString(key[:]), nil
+}
+
+// MakeMemoryStore is the MemoryStore constructor.
+func MakeMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]storeEntry)}
+}
+
+// MakeFileStore is the FileStore constructor.
+// Creates the directory dir if it doesn't exist.
+func MakeFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
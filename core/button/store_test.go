@@ -0,0 +1,210 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package button
+
+import (
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qioalice/devola/core/chat"
+	"github.com/qioalice/devola/core/errors"
+	"github.com/qioalice/devola/core/event"
+)
+
+// testLongArgs are args that don't fit the default callback data limit,
+// so Codec saves them to Store.
+var testLongArgs = []string{strings.Repeat("a", cMaxSizeDefault), "red"}
+
+// testStores returns the constructors of all Store backends.
+func testStores() []struct {
+	name string
+	make func(t *testing.T) Store
+} {
+	return []struct {
+		name string
+		make func(t *testing.T) Store
+	}{
+		{"memory", func(t *testing.T) Store { return MakeMemoryStore() }},
+		{"file", func(t *testing.T) Store { return openFileStore(t, t.TempDir()) }},
+	}
+}
+
+func TestCodecStore(t *testing.T) {
+	for _, tt := range testStores() {
+		t.Run(tt.name, func(t *testing.T) {
+
+			c := MakeCodec(testKey, ParamStore(tt.make(t), time.Hour))
+			idt := chat.NewIDT(42, 1)
+
+			d := &Data{ViewID: 3, SessionID: 7, Action: "buy", Args: testLongArgs}
+			s, code := c.Encode(idt, d)
+			if code != errors.ECOK {
+				t.Fatalf("Encode: unexpected code %v", code)
+			}
+
+			got, code := c.Decode(idt, d.SessionID, s)
+			if code != errors.ECOK {
+				t.Fatalf("Decode: unexpected code %v", code)
+			}
+			if !reflect.DeepEqual(got, d) {
+				t.Fatalf("Decode = %+v, want %+v", got, d)
+			}
+		})
+	}
+}
+
+func TestCodecStoreExpired(t *testing.T) {
+	for _, tt := range testStores() {
+		t.Run(tt.name, func(t *testing.T) {
+
+			// Args are expired as soon as they are saved.
+			c := MakeCodec(testKey, ParamStore(tt.make(t), -time.Second))
+			idt := chat.NewIDT(42, 1)
+
+			s, code := c.Encode(idt, &Data{ViewID: 3, SessionID: 7, Action: "buy", Args: testLongArgs})
+			if code != errors.ECOK {
+				t.Fatalf("Encode: unexpected code %v", code)
+			}
+
+			got, code := c.Decode(idt, 7, s)
+			if code != ECExpired {
+				t.Fatalf("Decode: got code %v, want ECExpired", code)
+			}
+			want := &Data{ViewID: 3, SessionID: 7, Action: "buy"}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("Decode = %+v, want %+v", got, want)
+			}
+
+			e := event.Event{Type: 1, Data: "buy", Args: []string{"42"}}
+			Reject(&e, code)
+			if e.Type != event.CTypeRejected || e.Data != CRejectExpired || e.Args != nil {
+				t.Fatalf("Reject: got %v %q %v, want rejected %q without args",
+					e.Type, e.Data, e.Args, CRejectExpired)
+			}
+		})
+	}
+}
+
+func TestStoreTTL(t *testing.T) {
+	for _, tt := range testStores() {
+		t.Run(tt.name, func(t *testing.T) {
+
+			store := tt.make(t)
+			mustSaveArgs(t, store, "live", []string{"1"}, time.Hour)
+			mustSaveArgs(t, store, "expired", []string{"2"}, -time.Second)
+
+			assertArgs(t, store, "live", []string{"1"})
+			assertArgs(t, store, "expired", nil)
+			assertArgs(t, store, "unknown", nil)
+		})
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+
+	s := MakeMemoryStore()
+	mustSaveArgs(t, s, "live", []string{"1"}, time.Hour)
+	mustSaveArgs(t, s, "expired", []string{"2"}, -time.Second)
+
+	s.Sweep()
+	if _, found := s.entries["expired"]; found {
+		t.Fatal("Sweep: expired args are not removed")
+	}
+	assertArgs(t, s, "live", []string{"1"})
+
+	// Expired args are removed each cStoreSweepEvery saves too.
+	mustSaveArgs(t, s, "expired", []string{"2"}, -time.Second)
+	for i := 0; i < cStoreSweepEvery-1; i++ {
+		mustSaveArgs(t, s, strconv.Itoa(i), []string{"3"}, time.Hour)
+	}
+	if _, found := s.entries["expired"]; found {
+		t.Fatalf("expired args are not removed after %d saves", cStoreSweepEvery)
+	}
+}
+
+func TestFileStoreSweep(t *testing.T) {
+
+	s := openFileStore(t, t.TempDir())
+	mustSaveArgs(t, s, "live", []string{"1"}, time.Hour)
+	mustSaveArgs(t, s, "expired", []string{"2"}, -time.Second)
+
+	if err := s.Sweep(); err != nil {
+		t.Fatalf("Sweep: unexpected error: %v", err)
+	}
+	if _, err := os.Stat(s.path("expired")); !os.IsNotExist(err) {
+		t.Fatalf("Sweep: file of expired args is not removed: %v", err)
+	}
+	assertArgs(t, s, "live", []string{"1"})
+}
+
+func TestFileStoreReopen(t *testing.T) {
+
+	dir := t.TempDir()
+	c := MakeCodec(testKey, ParamStore(openFileStore(t, dir), time.Hour))
+	idt := chat.NewIDT(42, 1)
+
+	d := &Data{ViewID: 3, SessionID: 7, Action: "buy", Args: testLongArgs}
+	s, code := c.Encode(idt, d)
+	if code != errors.ECOK {
+		t.Fatalf("Encode: unexpected code %v", code)
+	}
+
+	// Restart: the new Codec with the same key and the same directory.
+	c = MakeCodec(testKey, ParamStore(openFileStore(t, dir), time.Hour))
+
+	got, code := c.Decode(idt, d.SessionID, s)
+	if code != errors.ECOK {
+		t.Fatalf("Decode after reopen: unexpected code %v", code)
+	}
+	if !reflect.DeepEqual(got, d) {
+		t.Fatalf("Decode after reopen = %+v, want %+v", got, d)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: unexpected error: %v", err)
+	}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".tmp-") {
+			t.Fatalf("temporary file %s is left after Save", entry.Name())
+		}
+	}
+}
+
+// openFileStore returns FileStore of dir or fails t.
+func openFileStore(t *testing.T, dir string) *FileStore {
+	t.Helper()
+	s, err := MakeFileStore(dir)
+	if err != nil {
+		t.Fatalf("MakeFileStore: unexpected error: %v", err)
+	}
+	return s
+}
+
+// mustSaveArgs saves args to store under key for ttl or fails t.
+func mustSaveArgs(t *testing.T, store Store, key string, args []string, ttl time.Duration) {
+	t.Helper()
+	if err := store.Save(key, args, ttl); err != nil {
+		t.Fatalf("Save(%q): unexpected error: %v", key, err)
+	}
+}
+
+// assertArgs fails t if args loaded from store by key are not want
+// (nil want means there must be no args).
+func assertArgs(t *testing.T, store Store, key string, want []string) {
+	t.Helper()
+	args, found, err := store.Load(key)
+	if err != nil {
+		t.Fatalf("Load(%q): unexpected error: %v", key, err)
+	}
+	if found != (want != nil) || !reflect.DeepEqual(args, want) {
+		t.Fatalf("Load(%q) = %v, %v, want %v", key, args, found, want)
+	}
+}
@@ -43,6 +43,7 @@ const MaxTypeValue Type = 15
 // from/in IDT.
 const (
 	idtMaskID       IDT = 0x800FFFFFFFFFFFFF // Mask of ID (high sign bit + low 52 bits)
+	idtMaskIDBits   IDT = 0x000FFFFFFFFFFFFF // Mask of ID without sign bit (low 52 bits)
 	idtMaskType     IDT = 0x7800000000000000 // Mask of Type (high 8 bits after one)
 	idtMaskReserved IDT = 0x07F0000000000000 // Mask of reserved bits (high 3 bits after 8+1 bits)
 
@@ -51,6 +52,11 @@ const (
 
 // ID extracts and returns ID from IDT - a combination of ID and Type.
 func (idt IDT) ID() ID {
+	// Negative ID has all bits between sign bit and 52 bits of ID set
+	// (they are replaced by Type and reserved bits in IDT).
+	if idt&idtMaskID&^idtMaskIDBits != 0 {
+		return ID(idt | ^idtMaskID)
+	}
 	return ID(idt & idtMaskID)
 }
 
@@ -60,6 +66,8 @@ func (idt IDT) Type() Type {
 }
 
 // NewIDT creates a new IDT value by combining passed chat's ID and chat's Type.
+// id must fit in 53 bits (sign bit and 52 bits), typ must not be greater
+// than MaxTypeValue.
 func NewIDT(id ID, typ Type) IDT {
-	return (IDT(typ) << idtTypeSHR2uint8) & IDT(id)
+	return (IDT(typ)<<idtTypeSHR2uint8)&idtMaskType | IDT(id)&idtMaskID
 }
@@ -0,0 +1,34 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package chat
+
+import (
+	"testing"
+)
+
+func TestIDTRoundTrip(t *testing.T) {
+
+	ids := []ID{
+		0, 1, -1, 42, -42,
+		123456789, -1001234567890, // Telegram-like user and supergroup IDs
+		1<<52 - 1, -(1 << 52),     // bounds of 53 bits
+	}
+
+	for _, id := range ids {
+		for typ := Type(0); typ <= MaxTypeValue; typ++ {
+			idt := NewIDT(id, typ)
+			if got := idt.ID(); got != id {
+				t.Errorf("NewIDT(%d, %d).ID() = %d, want %d", id, typ, got, id)
+			}
+			if got := idt.Type(); got != typ {
+				t.Errorf("NewIDT(%d, %d).Type() = %d, want %d", id, typ, got, typ)
+			}
+			if idt&idtMaskReserved != 0 {
+				t.Errorf("NewIDT(%d, %d) = %#x, reserved bits are set", id, typ, uint64(idt))
+			}
+		}
+	}
+}
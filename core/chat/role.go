@@ -0,0 +1,21 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package chat
+
+// Role represents the role of chat member (creator, administrator,
+// regular member, etc) who is sent the event.
+//
+// As chat's Type, the set of roles is depended on backend,
+// so backend should declare its own constants of Role.
+type Role uint8
+
+// Predefined constants.
+const (
+
+	// Represents an unknown role (backend doesn't support roles
+	// or the role can not be determined).
+	CRoleUnknown Role = 0
+)
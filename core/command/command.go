@@ -0,0 +1,52 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package command
+
+import (
+	"strings"
+)
+
+// Command is one entry of command menu.
+// It's backend-neutral: it's generated by registrator
+// and can be pushed to backend using bridge.
+type Command struct {
+	Command     string `json:"command"`
+	Description string `json:"description"`
+	Usage       string `json:"usage,omitempty"`
+}
+
+// Commands is a command menu: a list of Command objects.
+// Has a method to represent it as help message.
+type Commands []Command
+
+// Help returns a help message: header (if it's not empty) and then
+// one line per command in the format "<usage> - <description>".
+func (cs Commands) Help(header string) string {
+
+	var b strings.Builder
+
+	if header != "" {
+		b.WriteString(header)
+		b.WriteString("\n\n")
+	}
+
+	for i, c := range cs {
+		if i != 0 {
+			b.WriteByte('\n')
+		}
+		usage := c.Usage
+		if usage == "" {
+			usage = c.Command
+		}
+		b.WriteString(usage)
+		if c.Description != "" {
+			b.WriteString(" - ")
+			b.WriteString(c.Description)
+		}
+	}
+
+	return b.String()
+}
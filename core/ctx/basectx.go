@@ -6,6 +6,7 @@
 package ctx
 
 import (
+	"github.com/qioalice/devola/core/chat"
 	"github.com/qioalice/devola/core/event"
 	"github.com/qioalice/devola/core/session"
 )
@@ -46,8 +47,26 @@ type BaseCtx struct {
 	//
 	// 2. Your backend-depended context type must have:
 	//    - event.Event as 1st embedded type,
-	//    - session.Session as 2nd embedded type.
+	//    - session.Session as 2nd embedded type,
+	//    - chat.IDT as 3rd field,
+	//    - chat.Role as 4th field.
+	//
+	// NOTE.
+	// Chat and SenderRole fields has been added after Session.
+	// Context types that follow the 2nd way and have only Event and Session
+	// fields must add them too, otherwise their next fields are overlapped
+	// by BaseCtx's ones.
+	//
+	// Use IsCompatible to check your context type
+	// (Registrator rejects incompatible types).
 
 	Event   event.Event
 	Session session.Session
+
+	// The chat (its ID and type) the event is occurred in.
+	Chat chat.IDT
+
+	// The role of chat member who is sent the event
+	// (chat.CRoleUnknown if backend can not determine it).
+	SenderRole chat.Role
 }
@@ -0,0 +1,49 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ctx
+
+import (
+	"reflect"
+)
+
+// baseCtxType is the type of BaseCtx, the layout all context types must follow.
+var baseCtxType = reflect.TypeOf(BaseCtx{})
+
+// IsCompatible reports whether typ (or the type typ points to) is a context
+// type that can be casted to BaseCtx (see BaseCtx for the layout rules):
+// it's BaseCtx itself, embeds compatible type as its 1st field
+// or has the same fields as BaseCtx (the same types at the same offsets)
+// as its first fields.
+func IsCompatible(typ reflect.Type) bool {
+
+	if typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	switch {
+	case typ == nil || typ.Kind() != reflect.Struct:
+		return false
+
+	case typ == baseCtxType:
+		return true
+
+	case typ.NumField() != 0 && typ.Field(0).Anonymous &&
+		typ.Field(0).Type.Kind() == reflect.Struct && IsCompatible(typ.Field(0).Type):
+		return true
+
+	case typ.NumField() < baseCtxType.NumField():
+		return false
+	}
+
+	for i := 0; i < baseCtxType.NumField(); i++ {
+		want, have := baseCtxType.Field(i), typ.Field(i)
+		if have.Type != want.Type || have.Offset != want.Offset {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,68 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ctx
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/qioalice/devola/core/chat"
+	"github.com/qioalice/devola/core/event"
+	"github.com/qioalice/devola/core/session"
+)
+
+func TestIsCompatible(t *testing.T) {
+
+	type embedded struct {
+		BaseCtx
+		Extra int
+	}
+
+	type nested struct {
+		embedded
+		More string
+	}
+
+	type sameFields struct {
+		E     event.Event
+		S     session.Session
+		Chat  chat.IDT
+		Role  chat.Role
+		Extra int
+	}
+
+	// Layout of BaseCtx before Chat and SenderRole fields.
+	type oldFields struct {
+		E     event.Event
+		S     session.Session
+		Extra int
+	}
+
+	type embeddedPtr struct {
+		*BaseCtx
+	}
+
+	tests := []struct {
+		typ  reflect.Type
+		want bool
+	}{
+		{reflect.TypeOf(BaseCtx{}), true},
+		{reflect.TypeOf(&BaseCtx{}), true},
+		{reflect.TypeOf(&embedded{}), true},
+		{reflect.TypeOf(&nested{}), true},
+		{reflect.TypeOf(&sameFields{}), true},
+		{reflect.TypeOf(&oldFields{}), false},
+		{reflect.TypeOf(&embeddedPtr{}), false},
+		{reflect.TypeOf(42), false},
+		{nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsCompatible(tt.typ); got != tt.want {
+			t.Errorf("IsCompatible(%v) = %v, want %v", tt.typ, got, tt.want)
+		}
+	}
+}
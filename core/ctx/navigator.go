@@ -0,0 +1,58 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ctx
+
+import (
+	"github.com/qioalice/devola/core/view"
+)
+
+// Navigator is the configuration of views navigation: how views are
+// encoded and checked, how deep the navigation history of sessions is
+// and how views are rendered when user goes back.
+//
+// Use BaseCtx's Push, Replace, Pop, Back and Reset methods with it.
+type Navigator struct {
+
+	// View ID converter views are encoded by.
+	conv *view.IDConv
+
+	// View graph the transitions are checked by (see ParamGraph).
+	// Nil if transitions are not checked.
+	graph *view.Graph
+
+	// Max depth of session's history (see ParamMaxDepth).
+	maxDepth int
+
+	// Called by BaseCtx.Back with the restored view (see ParamRender).
+	render func(c *BaseCtx, id view.ID)
+}
+
+// Predefined constants.
+const (
+
+	// Max depth of session's history by default.
+	cNavigatorMaxDepth = 16
+)
+
+// MakeNavigator is the Navigator constructor.
+//
+// Saves View ID converter conv and applies all params
+// (see ParamGraph, ParamMaxDepth, ParamRender).
+func MakeNavigator(conv *view.IDConv, params ...interface{}) *Navigator {
+
+	n := &Navigator{
+		conv:     conv,
+		maxDepth: cNavigatorMaxDepth,
+	}
+
+	for _, p := range params {
+		if p, ok := p.(param); ok && p != nil {
+			p(n)
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,38 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ctx
+
+import (
+	"github.com/qioalice/devola/core/view"
+)
+
+// param is an alias to function that takes a Navigator object and changes
+// its behaviour.
+// It uses as parameters for Navigator constructor.
+type param func(n *Navigator)
+
+// ParamGraph sets the view graph the transitions of Push and Replace
+// are checked by (see BaseCtx.Transit).
+// Pop, Back and Reset are not checked.
+func ParamGraph(g *view.Graph) param {
+	return func(n *Navigator) { n.graph = g }
+}
+
+// ParamMaxDepth sets the max depth of session's history (16 by default).
+// The oldest views are dropped when the history becomes deeper.
+// Zero or negative depth means the history is unbounded.
+func ParamMaxDepth(depth int) param {
+	return func(n *Navigator) { n.maxDepth = depth }
+}
+
+// ParamRender sets the hook that is called by BaseCtx.Back with the view
+// session is returned to, so it can be rendered again.
+//
+// c can be casted to the backend-depended context type
+// (see BaseCtx's axioms).
+func ParamRender(hook func(c *BaseCtx, id view.ID)) param {
+	return func(n *Navigator) { n.render = hook }
+}
@@ -0,0 +1,155 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package ctx
+
+import (
+	"github.com/qioalice/devola/core/errors"
+	"github.com/qioalice/devola/core/session"
+	"github.com/qioalice/devola/core/view"
+)
+
+// Transit changes the view of c.Session to view to (both View ID and
+// encoded View ID using idc).
+//
+// If g is not nil, the transition from the current view to to triggered
+// by c.Event is checked (see view.Graph.Check) and the code of that check
+// is returned. Illegal transition is not applied only if g is strict
+// (see view.ParamGraphStrict).
+func (c *BaseCtx) Transit(g *view.Graph, idc *view.IDConv, to view.ID) errors.Code {
+
+	if !to.IsValid() {
+		return view.ECInvalidID
+	}
+
+	to = idc.Resolve(to)
+
+	// Graph declares View ID templates, not View IDs made of them.
+	code := errors.ECOK
+	if g != nil {
+		code = g.Check(idc.Template(c.viewID(idc)), idc.Template(to), c.Event)
+	}
+
+	if code != errors.ECOK && g.IsStrict() {
+		return code
+	}
+
+	if setCode := c.set(idc, to); setCode != errors.ECOK {
+		return setCode
+	}
+
+	return code
+}
+
+// viewID returns the current View ID of c.Session
+// (decoded by idc if session has only encoded View ID)
+// or view.CIDNil if session has no view.
+func (c *BaseCtx) viewID(idc *view.IDConv) view.ID {
+
+	if c.Session.ViewID != view.CIDNil {
+		return idc.Resolve(c.Session.ViewID)
+	}
+
+	if c.Session.ViewIDEncoded != view.CIDEncNil {
+		if id, code := idc.Decode(c.Session.ViewIDEncoded); code == errors.ECOK {
+			return id
+		}
+	}
+
+	return view.CIDNil
+}
+
+// ViewParams returns the parsed parameters of the current view of c.Session
+// if it is made of View ID template registered in idc (see view.IDConv.Params)
+// or nil otherwise.
+func (c *BaseCtx) ViewParams(idc *view.IDConv) view.Params {
+	_, params := idc.Params(c.viewID(idc))
+	return params
+}
+
+// Push changes the view of c.Session to to (see Transit)
+// and saves the previous view to session's history.
+// The previous view is not saved if the transition is not applied
+// or the view is not changed.
+func (c *BaseCtx) Push(n *Navigator, to view.ID) errors.Code {
+
+	from := c.viewID(n.conv)
+
+	code := c.Transit(n.graph, n.conv, to)
+	if from != view.CIDNil && c.viewID(n.conv) != from {
+		c.Session.History.Push(from, n.maxDepth)
+	}
+
+	return code
+}
+
+// Replace changes the view of c.Session to to (see Transit)
+// without saving the previous view to session's history.
+func (c *BaseCtx) Replace(n *Navigator, to view.ID) errors.Code {
+	return c.Transit(n.graph, n.conv, to)
+}
+
+// Pop changes the view of c.Session to the most recent view of
+// session's history and returns it.
+//
+// Returns session.ECHistoryEmpty if there is no previous view
+// or view.ECNotRegistered if it can not be encoded.
+func (c *BaseCtx) Pop(n *Navigator) (view.ID, errors.Code) {
+
+	id, found := c.Session.History.Pop()
+	if !found {
+		return view.CIDNil, session.ECHistoryEmpty
+	}
+
+	if code := c.set(n.conv, id); code != errors.ECOK {
+		return view.CIDNil, code
+	}
+
+	return c.Session.ViewID, errors.ECOK
+}
+
+// Back is the same as Pop but also renders the restored view
+// (see ParamRender).
+func (c *BaseCtx) Back(n *Navigator) errors.Code {
+
+	id, code := c.Pop(n)
+	if code == errors.ECOK && n.render != nil {
+		n.render(c, id)
+	}
+
+	return code
+}
+
+// Reset clears session's history and changes the view of c.Session
+// to home ("Home" semantics). Only clears the history if home is view.CIDNil.
+func (c *BaseCtx) Reset(n *Navigator, home view.ID) errors.Code {
+
+	c.Session.History.Reset()
+
+	if home == view.CIDNil {
+		return errors.ECOK
+	}
+
+	return c.set(n.conv, home)
+}
+
+// set changes the view of c.Session to id (both View ID and
+// encoded View ID using idc) without any checks.
+func (c *BaseCtx) set(idc *view.IDConv, id view.ID) errors.Code {
+
+	if !id.IsValid() {
+		return view.ECInvalidID
+	}
+
+	id = idc.Resolve(id)
+
+	idenc := idc.Encode(id)
+	if idenc == view.CIDEncNil {
+		return view.ECNotRegistered
+	}
+
+	c.Session.ViewID, c.Session.ViewIDEncoded = id, idenc
+	return errors.ECOK
+}
@@ -102,3 +102,8 @@ func (be *BaseError) Error() string {
 func (be *BaseError) String() string {
 	return be.Error()
 }
+
+// MakeError creates a new BaseError object with passed error code and message.
+func MakeError(code Code, what string) *BaseError {
+	return &BaseError{code: code, what: what}
+}
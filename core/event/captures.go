@@ -0,0 +1,34 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package event
+
+// Captures represents a named parts of event's data that has been extracted
+// by pattern rule (prefix, glob or regex) the handler has been matched by.
+// This is a part of Event object, which is a part of backend Ctx.
+//
+// Thus if your handler is registered using some pattern,
+// you can always to get captured parts of event's data inside that handler.
+type Captures map[string]string
+
+// Predefined capture names.
+const (
+
+	// The name of capture that contains a part of event's data
+	// after the matched prefix (for prefix patterns only).
+	CCaptureRest = "rest"
+)
+
+// Get returns a captured value by its name or an empty string
+// if there is no capture with that name.
+func (c Captures) Get(name string) string {
+	return c[name]
+}
+
+// Has reports whether a capture with passed name is presented.
+func (c Captures) Has(name string) bool {
+	_, ok := c[name]
+	return ok
+}
@@ -19,6 +19,14 @@ type Event struct {
 
 	// The occurred event's data.
 	Data Data `json:"data,omitempty"`
+
+	// The named parts of occurred event's data.
+	// Not empty only if the handler has been matched by a pattern rule.
+	Captures Captures `json:"captures,omitempty"`
+
+	// The additional arguments of occurred event's data
+	// (e.g. decoded arguments of inline button, see button.Codec).
+	Args []string `json:"args,omitempty"`
 }
 
 // String returns a string representation of event.
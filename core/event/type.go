@@ -6,6 +6,7 @@
 package event
 
 import (
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -24,6 +25,18 @@ const (
 
 	// Marker of invalid type.
 	CTypeInvalid Type = 0
+
+	// Marker of event that is rejected by SDK before routing
+	// (forged, stale or expired inline button, etc).
+	// The event data is the reason of rejection (see button.Reject),
+	// so the handlers for rejected events can be registered as for any other.
+	// Backends must not use this value for their own types.
+	CTypeRejected Type = 255
+
+	// Marker of synthetic event of session expiration
+	// (see sweeper.Sweeper). The event data is empty.
+	// Backends must not use this value for their own types.
+	CTypeExpired Type = 254
 )
 
 // String returns a string representation of type.
@@ -51,7 +64,7 @@ var typeAliases = struct {
 	m  map[Type][]string
 	mu sync.RWMutex
 }{
-	m: make(map[Type][]string),
+	m: map[Type][]string{CTypeRejected: {"rejected"}, CTypeExpired: {"expired"}},
 }
 
 // TypeComment creates a comment for type t that allows to get that comment
@@ -76,3 +89,26 @@ func TypeComment(t Type, comment string) {
 
 	typeAliases.m[t] = append(v, comment)
 }
+
+// ParseType returns a type which comment (see TypeComment) is s
+// or which number is s.
+// Returns CTypeInvalid if there is no such type.
+func ParseType(s string) Type {
+
+	if n, err := strconv.ParseUint(s, 10, 8); err == nil {
+		return Type(n)
+	}
+
+	typeAliases.mu.RLock()
+	defer typeAliases.mu.RUnlock()
+
+	for t, comments := range typeAliases.m {
+		for _, comment := range comments {
+			if comment == s {
+				return t
+			}
+		}
+	}
+
+	return CTypeInvalid
+}
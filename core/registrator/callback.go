@@ -0,0 +1,228 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"reflect"
+	"unsafe"
+
+	"github.com/qioalice/devola/core/ctx"
+	"github.com/qioalice/devola/core/sys/fn"
+)
+
+// Callback is a registered handler or middleware.
+//
+// Callback keeps the registered function already wrapped to the form
+// that takes any backend context object (as untyped pointer),
+// so it can be called without knowing the real context type.
+// Callback objects are returned by Registrator.Match and Registrator.MatchCtx.
+type Callback struct {
+
+	// Exactly one of these fields is not nil.
+	handler    func(ctx unsafe.Pointer)
+	middleware func(ctx unsafe.Pointer) (isAllowed bool)
+	around     func(ctx unsafe.Pointer, next func())
+
+	// The name of registered function and the location it is declared at.
+	name string
+	file string
+	line int
+
+	// Is c unregistered by its Handle.
+	// Protected by Registrator's mutex.
+	isUnregistered bool
+
+	// The predicates of rule c is linked with (see Registrator.Where).
+	// Not nil only for guarded copies of registered callback
+	// (one copy per rule with predicates).
+	predicates []Predicate
+
+	// The registered callback c is a copy of (guarded or scoped one).
+	// Nil if c is the registered callback itself.
+	origin *Callback
+
+	// The handler group middleware c is registered for (see Group).
+	// Scoped middleware is called only if its handler has been matched.
+	// Not nil only for scoped copies of group middleware.
+	scope *Callback
+}
+
+// callbackKind represents a kind of registering callback.
+type callbackKind uint8
+
+// Predefined callback kinds.
+const (
+	kindHandler callbackKind = iota
+	kindMiddleware
+	kindAround
+)
+
+// isMiddleware reports whether k is a kind of any middleware.
+// Middlewares of all kinds are stored in the same storage sections.
+func (k callbackKind) isMiddleware() bool {
+	return k != kindHandler
+}
+
+// IsMiddleware reports whether c is middleware of any kind (not handler).
+func (c *Callback) IsMiddleware() bool {
+	return c != nil && (c.middleware != nil || c.around != nil)
+}
+
+// IsAround reports whether c is around middleware.
+func (c *Callback) IsAround() bool {
+	return c != nil && c.around != nil
+}
+
+// Name returns the full name of registered function.
+func (c *Callback) Name() string {
+	if c == nil {
+		return ""
+	}
+	return c.name
+}
+
+// guard returns a copy of c that is called only if all predicates
+// are satisfied by the context of occurred event.
+func (c *Callback) guard(predicates []Predicate) *Callback {
+	guarded := *c
+	guarded.predicates = predicates
+	guarded.origin = c.registered()
+	return &guarded
+}
+
+// scopeTo returns a copy of middleware c that is called only
+// if handler has been matched for occurred event.
+func (c *Callback) scopeTo(handler *Callback) *Callback {
+	scoped := *c
+	scoped.scope = handler
+	scoped.origin = c.registered()
+	return &scoped
+}
+
+// registered returns the registered callback c is a copy of or c itself.
+func (c *Callback) registered() *Callback {
+	if c.origin != nil {
+		return c.origin
+	}
+	return c
+}
+
+// isScopedIn reports whether c is not scoped middleware or its handler
+// is one of handlers.
+func (c *Callback) isScopedIn(handlers []*Callback) bool {
+
+	if c.scope == nil {
+		return true
+	}
+
+	for _, handler := range handlers {
+		if handler.registered() == c.scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isAllowedFor reports whether all c's predicates are satisfied by base.
+// Always false for guarded callbacks if base is nil.
+func (c *Callback) isAllowedFor(base *ctx.BaseCtx) bool {
+
+	if c.predicates == nil {
+		return true
+	}
+
+	if base == nil {
+		return false
+	}
+
+	for i := range c.predicates {
+		if !c.predicates[i].isSatisfiedBy(base) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Call calls c with passed backend context object ctx.
+// ctx must be a pointer to the object of context type
+// c has been registered for.
+//
+// Returns a value returned by middleware or true if c is handler.
+// Around middleware is called with next that does nothing,
+// and true is returned if it has been called.
+// Use Serve to call around middlewares with the rest of the chain.
+func (c *Callback) Call(ctx unsafe.Pointer) (isAllowed bool) {
+	switch {
+	case c == nil:
+		return false
+	case c.middleware != nil:
+		return c.middleware(ctx)
+	case c.around != nil:
+		c.around(ctx, func() { isAllowed = true })
+		return isAllowed
+	default:
+		c.handler(ctx)
+		return true
+	}
+}
+
+// makeCallback creates a new Callback object from passed handler or middleware
+// with already checked type ("func(*T)", "func(*T) bool" or "func(*T, func())"
+// depends on kind).
+//
+// It's an adapter of untyped (interface{}) Registrator's API.
+// cb is wrapped to the closure that converts untyped pointer to *T,
+// so cb is never called as a function of another type.
+// Callbacks of *ctx.BaseCtx are called directly, callbacks of other
+// context types are called using reflection (use For to avoid it).
+func makeCallback(cb interface{}, kind callbackKind) *Callback {
+
+	c := new(Callback)
+
+	switch f := cb.(type) {
+	case func(*ctx.BaseCtx):
+		c.handler = func(p unsafe.Pointer) { f((*ctx.BaseCtx)(p)) }
+	case func(*ctx.BaseCtx) bool:
+		c.middleware = func(p unsafe.Pointer) bool { return f((*ctx.BaseCtx)(p)) }
+	case func(*ctx.BaseCtx, func()):
+		c.around = func(p unsafe.Pointer, next func()) { f((*ctx.BaseCtx)(p), next) }
+	default:
+		makeReflectCallback(c, reflect.ValueOf(cb), kind)
+	}
+
+	c.name, c.file, c.line = fn.Describe(cb)
+	return c
+}
+
+// makeReflectCallback initializes c by the closure that calls function v
+// of context type T using reflection.
+func makeReflectCallback(c *Callback, v reflect.Value, kind callbackKind) {
+
+	ctxType := v.Type().In(0).Elem()
+	in := func(p unsafe.Pointer) []reflect.Value {
+		return []reflect.Value{reflect.NewAt(ctxType, p)}
+	}
+
+	switch kind {
+	case kindMiddleware:
+		c.middleware = func(p unsafe.Pointer) bool { return v.Call(in(p))[0].Bool() }
+	case kindAround:
+		c.around = func(p unsafe.Pointer, next func()) {
+			v.Call(append(in(p), reflect.ValueOf(next)))
+		}
+	default:
+		c.handler = func(p unsafe.Pointer) { v.Call(in(p)) }
+	}
+}
+
+// makeCallbackNamed is the same as makeCallback but takes a named function
+// (see fn.Named) which type is known and already checked.
+func makeCallbackNamed(named fn.Named, kind callbackKind) *Callback {
+	cb := reflect.NewAt(named.Type, named.Ptr).Elem().Interface()
+	return makeCallback(cb, kind)
+}
@@ -0,0 +1,59 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/qioalice/devola/core/ctx"
+	"github.com/qioalice/devola/core/sys/fn"
+)
+
+// testCtx is an extended context used by tests.
+type testCtx struct {
+	ctx.BaseCtx
+	calls []string
+}
+
+func TestMakeCallback(t *testing.T) {
+
+	tests := []struct {
+		name string
+		cb   interface{}
+		kind callbackKind
+		want []string
+	}{
+		{"base handler", func(c *ctx.BaseCtx) { (*testCtx)(unsafe.Pointer(c)).calls = []string{"h"} },
+			kindHandler, []string{"h"}},
+		{"handler", func(c *testCtx) { c.calls = append(c.calls, "h") }, kindHandler, []string{"h"}},
+		{"middleware", func(c *testCtx) bool { c.calls = append(c.calls, "m"); return true },
+			kindMiddleware, []string{"m"}},
+		{"around", func(c *testCtx, next func()) { c.calls = append(c.calls, "a"); next() },
+			kindAround, []string{"a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, cb := range []*Callback{
+				makeCallback(tt.cb, tt.kind),
+				makeCallbackNamed(fn.MakeNamed("cb", tt.cb), tt.kind),
+			} {
+				var c testCtx
+				if !cb.Call(unsafe.Pointer(&c)) {
+					t.Fatalf("Call: got false, want true")
+				}
+				if !reflect.DeepEqual(c.calls, tt.want) {
+					t.Fatalf("got calls %v, want %v", c.calls, tt.want)
+				}
+				if cb.Name() == "" {
+					t.Fatalf("callback has no name")
+				}
+			}
+		})
+	}
+}
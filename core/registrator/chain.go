@@ -0,0 +1,60 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"unsafe"
+)
+
+// Serve calls middlewares and then handlers with backend context object ctx
+// and returns true if handlers has been called.
+//
+// Middlewares are called in the passed order and can be mixed
+// (regular and around ones):
+//
+// - If regular middleware returns false, no one of next middlewares
+//   and handlers will be called.
+//
+// - Around middleware gets a next function that calls all next middlewares
+//   and handlers. The code of around middleware after next call is executed
+//   after them. If it doesn't call next, neither of them will be called.
+//   Calling next more than once has no effect.
+//
+// Typically middlewares and handlers are obtained by Registrator.Match.
+func Serve(ctx unsafe.Pointer, middlewares, handlers []*Callback) (isHandled bool) {
+	return serveFrom(ctx, middlewares, handlers)
+}
+
+// serveFrom is the Serve's core.
+// Calls middlewares one by one until around middleware is met,
+// which rest of chain is passed to as next.
+func serveFrom(ctx unsafe.Pointer, middlewares, handlers []*Callback) (isHandled bool) {
+
+	for i, mw := range middlewares {
+
+		if mw.around != nil {
+			isCalled := false
+			next := func() {
+				if !isCalled {
+					isCalled = true
+					isHandled = serveFrom(ctx, middlewares[i+1:], handlers)
+				}
+			}
+			mw.around(ctx, next)
+			return isHandled
+		}
+
+		if !mw.Call(ctx) {
+			return false
+		}
+	}
+
+	for _, h := range handlers {
+		h.Call(ctx)
+	}
+
+	return true
+}
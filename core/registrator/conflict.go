@@ -0,0 +1,368 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"strings"
+
+	"github.com/qioalice/devola/core/event"
+	"github.com/qioalice/devola/core/view"
+)
+
+// ConflictKind represents a kind of routes conflict that is found
+// by strict mode of Registrator or by Registrator.Check method.
+type ConflictKind uint8
+
+// Predefined conflict kinds.
+const (
+
+	// The same handler's route (event type, event data, pattern, View ID)
+	// is registered twice or more times.
+	// All handlers will be called, but it is probably a mistake.
+	ConflictDuplicate ConflictKind = 1
+
+	// The route will never be matched because another route always wins.
+	// For example a prefix pattern "/buy 1" after prefix pattern "/buy",
+	// or a handler without View ID when there are handlers with the same
+	// event type and data for each registered View ID.
+	ConflictShadowed ConflictKind = 2
+
+	// The middleware is registered for the route there is no handler for.
+	// So, it will never be called.
+	ConflictOrphanMiddleware ConflictKind = 3
+
+	// The route is registered for the view that is not declared
+	// in view graph (see Registrator.CheckGraph).
+	ConflictUnknownView ConflictKind = 4
+)
+
+// String returns a string representation of conflict kind.
+func (k ConflictKind) String() string {
+	switch k {
+	case ConflictDuplicate:
+		return "Duplicate"
+	case ConflictShadowed:
+		return "Shadowed"
+	case ConflictOrphanMiddleware:
+		return "Orphan middleware"
+	case ConflictUnknownView:
+		return "Unknown view"
+	}
+	return "Unknown"
+}
+
+// Conflict represents one found routes conflict.
+type Conflict struct {
+
+	// What kind of conflict is it.
+	Kind ConflictKind `json:"kind"`
+
+	// The route the conflict is found for.
+	Route Route `json:"route"`
+
+	// The routes Route conflicts with: the same routes for ConflictDuplicate,
+	// the routes that always win for ConflictShadowed.
+	// Empty for ConflictOrphanMiddleware and ConflictUnknownView.
+	With []Route `json:"with,omitempty"`
+}
+
+// String returns a string representation of conflict.
+func (c *Conflict) String() string {
+
+	if c == nil {
+		return ""
+	}
+
+	s := c.Kind.String() + ": " + c.Route.String()
+
+	if len(c.With) != 0 {
+		rs := make([]string, 0, len(c.With))
+		for i := range c.With {
+			rs = append(rs, c.With[i].String())
+		}
+		s += " (with: " + strings.Join(rs, "; ") + ")"
+	}
+
+	return s
+}
+
+// Check checks all registered routes and returns a not nil EConflict object
+// if there are duplicated routes, shadowed routes or middlewares
+// that are registered for the routes without handlers.
+//
+// Unlike strict mode (that checks routes at the registration time)
+// Check can find middlewares without handlers, so it's recommended to call it
+// after all callbacks has been registered (at startup).
+//
+// Handlers without View ID are reported as shadowed only if there are
+// handlers for the same event for each View ID registered in View ID converter.
+// It means they are unreachable only if your sessions always have View ID.
+func (r *Registrator) Check() *EConflict {
+
+	registrations := r.current().registrations
+
+	var conflicts []Conflict
+	for i, reg := range registrations {
+		conflicts = append(conflicts, r.conflictsOf(reg, registrations[:i])...)
+	}
+
+	conflicts = append(conflicts, r.shadowedByWhen(registrations)...)
+	conflicts = append(conflicts, r.orphanMiddlewares(registrations)...)
+
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	return makeEConflict(conflicts)
+}
+
+// CheckGraph checks that each View ID of all registered routes
+// is declared in g and returns a not nil EConflict object
+// with ConflictUnknownView conflicts if it's not so.
+//
+// View IDs are checked by their canonical names (see canonicalView):
+// aliases by View IDs they are renamed to, View IDs made of templates
+// by these templates (g declares templates, as ctx.BaseCtx.Transit checks).
+//
+// Call it at startup after all callbacks has been registered.
+func (r *Registrator) CheckGraph(g *view.Graph) *EConflict {
+
+	var conflicts []Conflict
+	for _, route := range r.Routes() {
+		for _, id := range route.When {
+			if !g.Has(r.canonicalView(id)) {
+				conflicts = append(conflicts, Conflict{Kind: ConflictUnknownView, Route: route})
+				break
+			}
+		}
+	}
+
+	if len(conflicts) == 0 {
+		return nil
+	}
+
+	return makeEConflict(conflicts)
+}
+
+// canonicalView returns the name of view id is the name of:
+// the View ID id is renamed to (see view.IDConv.Resolve) or the template
+// it is made of (see view.IDConv.Template).
+func (r *Registrator) canonicalView(id view.ID) view.ID {
+	return r.converter.Template(r.converter.Resolve(id))
+}
+
+// routeKey is a normalized target's identifier.
+// Targets with equal keys are matched by the same events.
+type routeKey struct {
+	typ     event.Type
+	data    event.Data
+	pattern PatternKind
+	viewID  view.IDEnc
+
+	// Canonical identity of rule's predicates (see predicatesKey).
+	// Routes with different predicates are not conflict.
+	predicates string
+}
+
+// keyOf returns a normalized routeKey of t.
+// Event data of "simple" types is ignored by exact rules (see Registrator).
+func (r *Registrator) keyOf(t *target) routeKey {
+	key := routeKey{t.rule.Type, r.foldCase(t.rule.Type, t.data), t.rule.Pattern, t.viewID, predicatesKey(t.rule.Predicates)}
+	if key.pattern == PatternExact && r.simplesChecker(key.typ) {
+		key.data = event.CDataNil
+	}
+	return key
+}
+
+// conflictsOf returns duplicated and shadowed conflicts of reg's routes
+// assuming that registrations has been registered before reg.
+func (r *Registrator) conflictsOf(reg *registration, registrations []*registration) []Conflict {
+
+	var conflicts []Conflict
+
+	for i := range reg.targets {
+		t := &reg.targets[i]
+		key := r.keyOf(t)
+
+		var duplicates, shadows []Route
+		for _, prev := range registrations {
+			if prev.isMiddleware != reg.isMiddleware {
+				continue
+			}
+			for j := range prev.targets {
+				u := &prev.targets[j]
+				prevKey := r.keyOf(u)
+				switch {
+				case prevKey == key && !reg.isMiddleware:
+					duplicates = append(duplicates, makeRoute(prev, u.rule))
+				case prevKey != key && isPatternShadowed(key, prevKey):
+					shadows = append(shadows, makeRoute(prev, u.rule))
+				}
+			}
+		}
+
+		if len(duplicates) != 0 {
+			conflicts = append(conflicts, Conflict{ConflictDuplicate, makeRoute(reg, t.rule), duplicates})
+		}
+		if len(shadows) != 0 {
+			conflicts = append(conflicts, Conflict{ConflictShadowed, makeRoute(reg, t.rule), shadows})
+		}
+	}
+
+	// Main handlers has no targets.
+	if reg.rules == nil && !reg.isMiddleware {
+		var duplicates []Route
+		for _, prev := range registrations {
+			if prev.rules == nil && !prev.isMiddleware {
+				duplicates = append(duplicates, makeRoute(prev, nil))
+			}
+		}
+		if len(duplicates) != 0 {
+			conflicts = append(conflicts, Conflict{ConflictDuplicate, makeRoute(reg, nil), duplicates})
+		}
+	}
+
+	return conflicts
+}
+
+// isPatternShadowed reports whether pattern of key will never be matched
+// because pattern of prev (that is registered before) is always matched first.
+//
+// Only obvious cases are detected: prefixes that covers other prefixes,
+// empty prefixes and globs that consist only of "*".
+func isPatternShadowed(key, prev routeKey) bool {
+
+	if key.typ != prev.typ || key.viewID != prev.viewID ||
+		key.pattern == PatternExact || prev.pattern == PatternExact {
+		return false
+	}
+
+	// Conditional route shadows only routes with the same conditions.
+	if prev.predicates != "" && prev.predicates != key.predicates {
+		return false
+	}
+
+	switch prev.pattern {
+
+	case PatternPrefix:
+		return prev.data == event.CDataNil ||
+			key.pattern == PatternPrefix && strings.HasPrefix(string(key.data), string(prev.data))
+
+	case PatternGlob:
+		return strings.Trim(string(prev.data), "*") == "" && prev.data != event.CDataNil
+	}
+
+	return false
+}
+
+// shadowedByWhen returns conflicts of handlers without View ID that are
+// shadowed by handlers with the same event for each registered View ID.
+func (r *Registrator) shadowedByWhen(registrations []*registration) []Conflict {
+
+	views := r.converter.IDs()
+	if len(views) == 0 {
+		return nil
+	}
+
+	// key without View ID -> View IDs encoded there are handlers for.
+	covered := make(map[routeKey]map[view.IDEnc][]Route)
+	for _, reg := range registrations {
+		if reg.isMiddleware {
+			continue
+		}
+		for i := range reg.targets {
+			t := &reg.targets[i]
+			if t.viewID == view.CIDEncNil {
+				continue
+			}
+			key := r.keyOf(t)
+			viewID := key.viewID
+			key.viewID = view.CIDEncNil
+			if covered[key] == nil {
+				covered[key] = make(map[view.IDEnc][]Route)
+			}
+			covered[key][viewID] = append(covered[key][viewID], makeRoute(reg, t.rule))
+		}
+	}
+
+	var conflicts []Conflict
+	for _, reg := range registrations {
+		if reg.isMiddleware {
+			continue
+		}
+		for i := range reg.targets {
+			t := &reg.targets[i]
+			if t.viewID != view.CIDEncNil {
+				continue
+			}
+			byView := covered[r.keyOf(t)]
+			if len(byView) < len(views) {
+				continue
+			}
+			var shadows []Route
+			for _, id := range views {
+				shadows = append(shadows, byView[r.converter.Encode(id)]...)
+			}
+			conflicts = append(conflicts, Conflict{ConflictShadowed, makeRoute(reg, t.rule), shadows})
+		}
+	}
+
+	return conflicts
+}
+
+// orphanMiddlewares returns conflicts of middlewares that are registered
+// for the routes without handlers.
+//
+// Middleware with View ID is not orphan if there is a handler for the same event
+// with the same View ID or without View ID at all.
+// Middleware without View ID is not orphan if there is a handler
+// for the same event with any View ID.
+// Predicates of middlewares and handlers are not considered.
+func (r *Registrator) orphanMiddlewares(registrations []*registration) []Conflict {
+
+	// exact keys of handlers and keys of handlers with View ID dropped.
+	handled := make(map[routeKey]struct{})
+	handledAnyView := make(map[routeKey]struct{})
+
+	for _, reg := range registrations {
+		if reg.isMiddleware {
+			continue
+		}
+		for i := range reg.targets {
+			key := r.keyOf(&reg.targets[i])
+			key.predicates = ""
+			handled[key] = struct{}{}
+			key.viewID = view.CIDEncNil
+			handledAnyView[key] = struct{}{}
+		}
+	}
+
+	var conflicts []Conflict
+	for _, reg := range registrations {
+		if !reg.isMiddleware {
+			continue
+		}
+		for i := range reg.targets {
+			t := &reg.targets[i]
+			key := r.keyOf(t)
+			key.predicates = ""
+
+			var found bool
+			if key.viewID == view.CIDEncNil {
+				_, found = handledAnyView[key]
+			} else if _, found = handled[key]; !found {
+				key.viewID = view.CIDEncNil
+				_, found = handled[key]
+			}
+
+			if !found {
+				conflicts = append(conflicts, Conflict{ConflictOrphanMiddleware, makeRoute(reg, t.rule), nil})
+			}
+		}
+	}
+
+	return conflicts
+}
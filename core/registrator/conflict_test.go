@@ -0,0 +1,97 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"testing"
+
+	"github.com/qioalice/devola/core/ctx"
+	"github.com/qioalice/devola/core/view"
+)
+
+func TestConflictPredicates(t *testing.T) {
+
+	isAdmin := func(*ctx.BaseCtx) bool { return true }
+	isOwner := func(*ctx.BaseCtx) bool { return false }
+	shared := MakePredicate("shared", isAdmin)
+
+	tests := []struct {
+		name       string
+		first      []Predicate
+		second     []Predicate
+		isConflict bool
+	}{
+		{"no predicates", nil, nil, true},
+		{"same chat types", []Predicate{ChatType(1, 2)}, []Predicate{ChatType(1, 2)}, true},
+		{"chat types order", []Predicate{ChatType(1, 2)}, []Predicate{ChatType(2, 1)}, true},
+		{"chat types duplicates", []Predicate{ChatType(1, 1, 2)}, []Predicate{ChatType(2, 1)}, true},
+		{"different chat types", []Predicate{ChatType(1)}, []Predicate{ChatType(1, 2)}, false},
+		{"chat type and role", []Predicate{ChatType(1)}, []Predicate{SenderRole(1)}, false},
+		{"predicates order", []Predicate{ChatType(1), SenderRole(2)},
+			[]Predicate{SenderRole(2), ChatType(1)}, true},
+		{"same custom predicate", []Predicate{shared}, []Predicate{shared}, true},
+		{"same name, different funcs", []Predicate{MakePredicate("p", isAdmin)},
+			[]Predicate{MakePredicate("p", isOwner)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			r := makeTestRegistrator(ParamStrict(true))
+			handler := func(*ctx.BaseCtx) {}
+
+			if _, err := r.Complex(testTypeCommand, "/start", nil).Where(tt.first...).RegisterHandler(handler); err != nil {
+				t.Fatalf("first RegisterHandler: unexpected error: %v", err)
+			}
+
+			_, err := r.Complex(testTypeCommand, "/start", nil).Where(tt.second...).RegisterHandler(handler)
+			if isConflict := err != nil && err.Code() == ECRoutesConflict; isConflict != tt.isConflict {
+				t.Fatalf("got error %v, want conflict %v", err, tt.isConflict)
+			}
+		})
+	}
+}
+
+func TestCheckGraph(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		when      string
+		isUnknown bool
+	}{
+		{"declared", "cart", false},
+		{"alias of declared", "old_cart", false},
+		{"made of declared template", "order/42", false},
+		{"declared template", "order/:id:int", false},
+		{"alias of not declared", "old_menu", true},
+		{"not declared", "profile", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			r := makeTestRegistrator()
+			_ = r.converter.Register("order/:id:int")
+			_ = r.converter.Alias("old_cart", "cart")
+			_ = r.converter.Alias("old_menu", "menu")
+
+			// Aliases must not pass under their own names.
+			g := view.MakeGraph().View("cart", "old_menu", "order/:id:int")
+
+			if err := r.Complex(testTypeCommand, "/start", []string{tt.when}).Handler(func(*ctx.BaseCtx) {}); err != nil {
+				t.Fatalf("Handler: unexpected error: %v", err)
+			}
+
+			err := r.CheckGraph(g)
+			if isUnknown := err != nil; isUnknown != tt.isUnknown {
+				t.Fatalf("CheckGraph: got %v, want unknown view %v", err, tt.isUnknown)
+			}
+			if err != nil && err.Conflicts[0].Kind != ConflictUnknownView {
+				t.Fatalf("CheckGraph: got %v, want ConflictUnknownView", err)
+			}
+		})
+	}
+}
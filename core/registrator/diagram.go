@@ -0,0 +1,279 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/qioalice/devola/core/event"
+	"github.com/qioalice/devola/core/view"
+)
+
+// Diagram is the navigation diagram of bot: views are nodes and events
+// handled in them are edges labelled with the names of handlers.
+//
+// Diagram is generated by Registrator.Diagram and can be rendered
+// as Graphviz DOT (see DOT) or as Mermaid flowchart (see Mermaid).
+type Diagram struct {
+
+	// All views (nodes) in the order they has been registered (or declared).
+	// Routes without View ID are linked to the special "any view" node.
+	Views []view.ID `json:"views"`
+
+	// All edges in the order routes (and then transitions) has been registered.
+	Edges []Edge `json:"edges"`
+}
+
+// Edge is one edge of Diagram: an event handled in view From
+// that leads to view To.
+//
+// From is view.CIDNil for routes without View ID ("any view" node).
+// To is the same as From if there is no declared transition for the event
+// in view graph.
+type Edge struct {
+	From view.ID `json:"from,omitempty"`
+	To   view.ID `json:"to,omitempty"`
+
+	// Event type and data (pattern) the edge is triggered by.
+	Event string `json:"event"`
+
+	// The name of handler (without package path).
+	// Empty for transitions of view graph there is no handlers for.
+	Handler string `json:"handler,omitempty"`
+}
+
+// Label returns the label of e: its event and handler.
+func (e *Edge) Label() string {
+	if e.Handler == "" {
+		return e.Event
+	}
+	return e.Event + ": " + e.Handler
+}
+
+// Diagram returns the navigation diagram of all registered handlers
+// (except fallback and main ones) and views registered in View ID converter.
+//
+// If g is not nil, its views are added too, and the edges lead to
+// the views of transitions declared for the events of handlers.
+// Declared transitions there is no handlers for are added as edges
+// without handler.
+//
+// Views are nodes by their canonical names (see canonicalView):
+// aliases are the same nodes as View IDs they are renamed to,
+// View IDs made of templates are the same nodes as these templates.
+func (r *Registrator) Diagram(g *view.Graph) *Diagram {
+
+	d := new(Diagram)
+	seenViews := make(map[view.ID]struct{})
+
+	addView := func(id view.ID) {
+		if _, found := seenViews[id]; !found {
+			seenViews[id] = struct{}{}
+			d.Views = append(d.Views, id)
+		}
+	}
+
+	for _, id := range r.converter.IDs() {
+		addView(r.canonicalView(id))
+	}
+
+	var transitions []view.Transition
+	if g != nil {
+		for _, id := range g.Views() {
+			addView(r.canonicalView(id))
+		}
+		seenTransitions := make(map[view.Transition]struct{})
+		for _, t := range g.Transitions(view.CIDNil) {
+			t.From, t.To = r.canonicalView(t.From), r.canonicalView(t.To)
+			if _, found := seenTransitions[t]; !found {
+				seenTransitions[t] = struct{}{}
+				transitions = append(transitions, t)
+			}
+		}
+	}
+
+	usedTransitions := make(map[view.Transition]struct{})
+
+	for _, route := range r.Routes() {
+		if route.IsMiddleware || route.IsMain || route.IsFallback {
+			continue
+		}
+
+		from := []view.ID{view.CIDNil}
+		if len(route.When) != 0 {
+			from = r.canonicalViews(route.When)
+		}
+
+		label, handler := diagramEvent(route.Type, route.Data, route.Pattern), diagramName(route.Name)
+		for _, id := range from {
+			if id != view.CIDNil {
+				addView(id)
+			}
+
+			isLinked := false
+			for _, t := range transitions {
+				if (id == view.CIDNil || t.From == id) && route.triggers(t) {
+					d.Edges = append(d.Edges, Edge{id, t.To, label, handler})
+					usedTransitions[t] = struct{}{}
+					isLinked = true
+				}
+			}
+
+			if !isLinked {
+				d.Edges = append(d.Edges, Edge{id, id, label, handler})
+			}
+		}
+	}
+
+	for _, t := range transitions {
+		if _, found := usedTransitions[t]; !found {
+			d.Edges = append(d.Edges, Edge{t.From, t.To, diagramEvent(t.Type, t.Data, PatternExact), ""})
+		}
+	}
+
+	return d
+}
+
+// canonicalViews returns the canonical names of ids (see canonicalView)
+// without duplicates.
+func (r *Registrator) canonicalViews(ids []view.ID) []view.ID {
+	canonical := make([]view.ID, 0, len(ids))
+	for _, id := range ids {
+		canonical = mergeViewIDs(canonical, []view.ID{r.canonicalView(id)})
+	}
+	return canonical
+}
+
+// DOT returns the Graphviz DOT representation of d.
+// Edges without handlers are dashed.
+func (d *Diagram) DOT() string {
+
+	var b strings.Builder
+	b.WriteString("digraph views {\n")
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=box];\n")
+
+	for _, id := range d.nodes() {
+		fmt.Fprintf(&b, "\t%s [label=%s];\n", dotQuote(diagramNode(id)), dotQuote(diagramNode(id)))
+	}
+
+	for i := range d.Edges {
+		e := &d.Edges[i]
+		style := ""
+		if e.Handler == "" {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(&b, "\t%s -> %s [label=%s%s];\n",
+			dotQuote(diagramNode(e.From)), dotQuote(diagramNode(e.To)), dotQuote(e.Label()), style)
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid returns the Mermaid flowchart representation of d.
+// Edges without handlers are dotted.
+func (d *Diagram) Mermaid() string {
+
+	nodes := d.nodes()
+	names := make(map[view.ID]string, len(nodes))
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+
+	for i, id := range nodes {
+		names[id] = fmt.Sprintf("v%d", i)
+		fmt.Fprintf(&b, "    %s[%s]\n", names[id], mermaidQuote(diagramNode(id)))
+	}
+
+	for i := range d.Edges {
+		e := &d.Edges[i]
+		arrow := "-->"
+		if e.Handler == "" {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "    %s %s|%s| %s\n", names[e.From], arrow, mermaidQuote(e.Label()), names[e.To])
+	}
+
+	return b.String()
+}
+
+// nodes returns d.Views and the "any view" node (view.CIDNil)
+// if some edge starts from it.
+func (d *Diagram) nodes() []view.ID {
+	for i := range d.Edges {
+		if d.Edges[i].From == view.CIDNil {
+			return append([]view.ID{view.CIDNil}, d.Views...)
+		}
+	}
+	return d.Views
+}
+
+// triggers reports whether the event of route triggers transition t.
+func (r *Route) triggers(t view.Transition) bool {
+
+	if t.Type != event.CTypeInvalid && t.Type != r.Type {
+		return false
+	}
+
+	if t.Data == event.CDataNil || t.Data == r.Data {
+		return true
+	}
+
+	for _, alias := range r.Aliases {
+		if t.Data == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// diagramEvent returns the label of event of type typ with data
+// compared by pattern.
+func diagramEvent(typ event.Type, data event.Data, pattern PatternKind) string {
+
+	s := "*"
+	if typ != event.CTypeInvalid {
+		s = typ.String()
+	}
+
+	if data != event.CDataNil {
+		s += fmt.Sprintf(" %q", string(data))
+	}
+
+	if pattern != PatternExact {
+		s += " (" + pattern.String() + ")"
+	}
+
+	return s
+}
+
+// diagramName returns the name of function without package path.
+func diagramName(name string) string {
+	if i := strings.LastIndexByte(name, '/'); i != -1 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// diagramNode returns the label of view id node.
+func diagramNode(id view.ID) string {
+	if id == view.CIDNil {
+		return "any view"
+	}
+	return string(id)
+}
+
+// dotQuote returns s as quoted DOT ID.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// mermaidQuote returns s as quoted Mermaid text.
+func mermaidQuote(s string) string {
+	return `"` + strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(s) + `"`
+}
@@ -0,0 +1,119 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/qioalice/devola/core/ctx"
+	"github.com/qioalice/devola/core/event"
+	"github.com/qioalice/devola/core/view"
+)
+
+// Handlers of diagram test (named ones, so their names are stable).
+func diagramCart(*ctx.BaseCtx)  {}
+func diagramOrder(*ctx.BaseCtx) {}
+func diagramShow(*ctx.BaseCtx)  {}
+func diagramHelp(*ctx.BaseCtx)  {}
+
+// makeDiagramRegistrator returns a Registrator and a view graph which views
+// are referred by aliases and templates.
+func makeDiagramRegistrator(t *testing.T) (*Registrator, *view.Graph) {
+
+	event.TypeComment(testTypeCommand, "command")
+	event.TypeComment(testTypeButton, "button")
+
+	r := makeTestRegistrator()
+	_ = r.converter.Register("main", "order/:id:int")
+	_ = r.converter.Alias("old_cart", "cart")
+
+	g := view.MakeGraph().
+		Entry("main").
+		Transition("main", "cart", testTypeCommand, "/cart").
+		Transition("old_cart", "order/:id:int", testTypeButton, "order").
+		Transition("cart", "order/:id:int", testTypeButton, "order"). // the same as above
+		Transition("old_cart", "main", testTypeCommand, "/back")
+
+	must := func(err *EBadCallback) {
+		if err != nil {
+			t.Fatalf("unexpected registration error: %v", err)
+		}
+	}
+
+	must(r.Complex(testTypeCommand, "/cart", []string{"main"}).Handler(diagramCart))
+	must(r.Complex(testTypeButton, "order", []string{"old_cart", "cart"}).Handler(diagramOrder))
+	must(r.Complex(testTypeCommand, "/show", []string{"order/42"}).Handler(diagramShow))
+	must(r.Complex(testTypeCommand, "/help", nil).Handler(diagramHelp))
+
+	return r, g
+}
+
+func TestDiagram(t *testing.T) {
+
+	r, g := makeDiagramRegistrator(t)
+	d := r.Diagram(g)
+
+	want := &Diagram{
+		Views: []view.ID{"main", "order/:id:int", "cart"},
+		Edges: []Edge{
+			{"main", "cart", `command "/cart"`, "registrator.diagramCart"},
+			{"cart", "order/:id:int", `button "order"`, "registrator.diagramOrder"},
+			{"order/:id:int", "order/:id:int", `command "/show"`, "registrator.diagramShow"},
+			{"", "", `command "/help"`, "registrator.diagramHelp"},
+			{"cart", "main", `command "/back"`, ""},
+		},
+	}
+
+	if !reflect.DeepEqual(d, want) {
+		t.Fatalf("Diagram:\ngot  %+v\nwant %+v", d, want)
+	}
+}
+
+func TestDiagramDOT(t *testing.T) {
+
+	r, g := makeDiagramRegistrator(t)
+
+	const want = `digraph views {
+	rankdir=LR;
+	node [shape=box];
+	"any view" [label="any view"];
+	"main" [label="main"];
+	"order/:id:int" [label="order/:id:int"];
+	"cart" [label="cart"];
+	"main" -> "cart" [label="command \"/cart\": registrator.diagramCart"];
+	"cart" -> "order/:id:int" [label="button \"order\": registrator.diagramOrder"];
+	"order/:id:int" -> "order/:id:int" [label="command \"/show\": registrator.diagramShow"];
+	"any view" -> "any view" [label="command \"/help\": registrator.diagramHelp"];
+	"cart" -> "main" [label="command \"/back\"", style=dashed];
+}
+`
+
+	if got := r.Diagram(g).DOT(); got != want {
+		t.Fatalf("DOT:\ngot\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDiagramMermaid(t *testing.T) {
+
+	r, g := makeDiagramRegistrator(t)
+
+	const want = `flowchart LR
+    v0["any view"]
+    v1["main"]
+    v2["order/:id:int"]
+    v3["cart"]
+    v1 -->|"command #quot;/cart#quot;: registrator.diagramCart"| v3
+    v3 -->|"button #quot;order#quot;: registrator.diagramOrder"| v2
+    v2 -->|"command #quot;/show#quot;: registrator.diagramShow"| v2
+    v0 -->|"command #quot;/help#quot;: registrator.diagramHelp"| v0
+    v3 -.->|"command #quot;/back#quot;"| v1
+`
+
+	if got := r.Diagram(g).Mermaid(); got != want {
+		t.Fatalf("Mermaid:\ngot\n%s\nwant\n%s", got, want)
+	}
+}
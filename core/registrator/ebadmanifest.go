@@ -0,0 +1,168 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"strconv"
+
+	"github.com/qioalice/devola/core/errors"
+)
+
+// ManifestProblem represents a reason why the routes manifest
+// can not be loaded.
+type ManifestProblem uint8
+
+// Predefined manifest problems.
+const (
+
+	// Manifest is not a valid JSON or has wrong structure.
+	ManifestSyntax ManifestProblem = 1
+
+	// There is no callback with requested name in the registry.
+	ManifestMissingCallback ManifestProblem = 2
+
+	// The type of callback is incompatible with required type by context.
+	ManifestBadCallback ManifestProblem = 3
+
+	// Requested View ID is invalid or is not registered in View ID converter.
+	ManifestUnknownView ManifestProblem = 4
+
+	// Requested event type is not a number and there is no type with such
+	// comment (see event.TypeComment).
+	ManifestUnknownType ManifestProblem = 5
+
+	// The route is inconsistent (invalid regular expression,
+	// nothing to register, middlewares for fallback handler, etc).
+	ManifestBadRoute ManifestProblem = 6
+)
+
+// String returns a string representation of manifest problem.
+func (p ManifestProblem) String() string {
+	switch p {
+	case ManifestSyntax:
+		return "Syntax"
+	case ManifestMissingCallback:
+		return "Missing callback"
+	case ManifestBadCallback:
+		return "Bad callback"
+	case ManifestUnknownView:
+		return "Unknown view"
+	case ManifestUnknownType:
+		return "Unknown event type"
+	case ManifestBadRoute:
+		return "Bad route"
+	}
+	return "Unknown"
+}
+
+// EBadManifest represents an SDK error that occurred while the routes manifest
+// has been tried to load to the Registrator.
+//
+// Returned by LoadManifest and LoadManifestJSON methods of Registrator type.
+//
+// You can figure out what kind of problem is occurred using Problem field
+// or using IsIt method:
+//
+// "e.IsIt((*EBadManifest)(nil))" covers all manifest errors,
+// "e.IsIt(&EBadManifest{ Problem: ManifestUnknownView })" - only unknown views.
+type EBadManifest struct {
+
+	// Problem is the reason why the manifest can not be loaded.
+	Problem ManifestProblem
+
+	// Route is an index of route in the manifest the problem is related to,
+	// or -1 if problem is related to the whole manifest.
+	Route int
+
+	// Name is the name of callback, View ID or event type
+	// the problem is related to. Contains the error's description
+	// for ManifestSyntax and ManifestBadRoute problems.
+	Name string
+
+	// WantType, HaveType are the string representations of the type
+	// callback should have and the type it has.
+	// Not empty only for ManifestBadCallback problem.
+	WantType string
+	HaveType string
+}
+
+// Code returns ECBadManifest if e is not nil, and errors.ECOK otherwise.
+func (e *EBadManifest) Code() errors.Code {
+	if e == nil {
+		return errors.ECOK
+	}
+	return ECBadManifest
+}
+
+// What returns a predefined description of e's problem,
+// or empty string if there's no error.
+func (e *EBadManifest) What() string {
+	switch {
+	case e == nil:
+		return ""
+	case e.Problem == ManifestSyntax:
+		return "The routes manifest can not be parsed."
+	case e.Problem == ManifestMissingCallback:
+		return "The callback of the route is not registered."
+	case e.Problem == ManifestBadCallback:
+		return "The type of the route's callback is incompatible with required type by context."
+	case e.Problem == ManifestUnknownView:
+		return "The View ID of the route is not registered."
+	case e.Problem == ManifestUnknownType:
+		return "The event type of the route is unknown."
+	}
+	return "The route is inconsistent."
+}
+
+// IsIt returns true when e2 is EBadManifest but nil
+// or not nil but its Problem field is the same as e's
+// or pointer to e2 and pointer to e are equals.
+func (e *EBadManifest) IsIt(e2 error) bool {
+	e2t, ok := e2.(*EBadManifest)
+	return ok && (e2t == e || e2t == nil ||
+		e2t != nil && e != nil && e.Problem == e2t.Problem)
+}
+
+// Error returns a string representation of error in the following format:
+// "<e.What()> Route: <I>, Name: <N>[, Want type: <T1>, Have type: <T2>]",
+// where I - route's index, N - the name problem is related to,
+// T1 - desired callback's type, T2 - current callback's type.
+//
+// Returns an empty string if e is nil.
+func (e *EBadManifest) Error() string {
+
+	if e == nil {
+		return ""
+	}
+
+	s := e.What()
+	if e.Route >= 0 {
+		s += " Route: " + strconv.Itoa(e.Route) + ","
+	}
+	s += " Name: " + e.Name
+
+	if e.Problem == ManifestBadCallback {
+		s += ", Want type: " + e.WantType + ", Have type: " + e.HaveType
+	}
+
+	return s
+}
+
+// String returns a string representation of error (the same as Error).
+//
+// Returns an empty string if e is nil.
+func (e *EBadManifest) String() string {
+	return e.Error()
+}
+
+// makeEBadManifest creates a new EBadManifest object with the passed params.
+func makeEBadManifest(problem ManifestProblem, route int, name string) *EBadManifest {
+	return &EBadManifest{
+		Problem: problem,
+		Route:   route,
+		Name:    name,
+	}
+}
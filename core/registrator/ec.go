@@ -12,10 +12,11 @@ import (
 // Predefined error codes of all registration operations.
 //
 // Registrator.Handler, Registrator.Middleware,
-// Registrator.MainHandler, Registrator.MainMiddleware may return an error object
-// EBadCallback, that implements errors.Error interface.
+// Registrator.MainHandler, Registrator.MainMiddleware (and their Register*
+// variants) may return an error object EBadCallback or EConflict (strict mode only),
+// that implements errors.Error interface.
 //
-// So, method EBadCallback.Code returns one of these constant
+// So, methods EBadCallback.Code and EConflict.Code return one of these constant
 // (or errors.ECOK if all is good).
 const (
 
@@ -26,4 +27,23 @@ const (
 	// Bad type of callback that is registering as middleware.
 	// (passed middleware has incompatible type with context type).
 	ECBadMiddleware errors.Code = 12
+
+	// Registering callback conflicts with already registered callbacks
+	// (duplicated or shadowed routes, middlewares without handlers).
+	// Returned only in strict mode or by Registrator.Check method.
+	ECRoutesConflict errors.Code = 13
+
+	// Registrator is frozen and no callbacks can be registered
+	// or unregistered anymore (see Registrator.Freeze).
+	ECFrozen errors.Code = 14
+
+	// Routes manifest can not be loaded (syntax error, unknown callback,
+	// view or event type, bad callback type).
+	// Returned only by Registrator.LoadManifest and LoadManifestJSON methods.
+	ECBadManifest errors.Code = 15
+
+	// Context type has layout incompatible with ctx.BaseCtx
+	// (see ctx.IsCompatible).
+	// Returned only by Registrator.RegenerateRequiredTypes method.
+	ECBadContext errors.Code = 16
 )
@@ -0,0 +1,131 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"strings"
+
+	"github.com/qioalice/devola/core/errors"
+)
+
+// EConflict represents an SDK error that reports about conflicts between
+// registered routes: duplicated routes, shadowed routes, middlewares
+// registered for the routes without handlers and routes registered
+// for undeclared views.
+//
+// Returned by Handler, MainHandler, Middleware, MainMiddleware methods of
+// Registrator type only in strict mode (see ParamStrict)
+// and by Registrator.Check and Registrator.CheckGraph methods.
+//
+// You can figure out what kind of error is occurred using Code method:
+//
+// "e.Code() == ECRoutesConflict"
+//
+// or using IsIt method:
+//
+// "e.IsIt((*EConflict)(nil))" covers all routes conflicts,
+// "e.IsIt(&EConflict{ Conflicts: []Conflict{{ Kind: ConflictDuplicate }} })"
+// covers only errors that reports about duplicated routes.
+type EConflict struct {
+
+	// All found conflicts in the order they has been found.
+	Conflicts []Conflict
+}
+
+// Code returns ECRoutesConflict if e is not nil and errors.ECOK otherwise.
+func (e *EConflict) Code() errors.Code {
+	if e == nil {
+		return errors.ECOK
+	}
+	return ECRoutesConflict
+}
+
+// What returns a predefined description of routes conflict error,
+// or empty string if there's no error.
+func (e *EConflict) What() string {
+	if e == nil {
+		return ""
+	}
+	return "The registering routes conflict with already registered ones."
+}
+
+// Has reports whether e has at least one conflict of kind.
+func (e *EConflict) Has(kind ConflictKind) bool {
+	if e == nil {
+		return false
+	}
+	for i := range e.Conflicts {
+		if e.Conflicts[i].Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// IsIt returns true when e2 is EConflict but nil
+// or not nil but e has conflicts of all kinds e2 has
+// or pointer to e2 and pointer to e are equals.
+func (e *EConflict) IsIt(e2 error) bool {
+
+	e2t, ok := e2.(*EConflict)
+	if !ok {
+		return false
+	}
+
+	if e2t == e || e2t == nil {
+		return true
+	}
+
+	if e == nil {
+		return false
+	}
+
+	for i := range e2t.Conflicts {
+		if !e.Has(e2t.Conflicts[i].Kind) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Error returns a string representation of error in the following format:
+// "<e.What()> Conflicts: <C>",
+// where C - all found conflicts.
+//
+// Returns an empty string if e is nil.
+func (e *EConflict) Error() string {
+
+	if e == nil {
+		return ""
+	}
+
+	s := e.What()
+
+	if len(e.Conflicts) != 0 {
+		cs := make([]string, 0, len(e.Conflicts))
+		for i := range e.Conflicts {
+			cs = append(cs, e.Conflicts[i].String())
+		}
+		s += " Conflicts: " + strings.Join(cs, ", ")
+	}
+
+	return s
+}
+
+// String returns a string representation of error in the following format:
+// "<e.What()> Conflicts: <C>",
+// where C - all found conflicts.
+//
+// Returns an empty string if e is nil.
+func (e *EConflict) String() string {
+	return e.Error()
+}
+
+// makeEConflict creates a new EConflict object with passed conflicts.
+func makeEConflict(conflicts []Conflict) *EConflict {
+	return &EConflict{Conflicts: conflicts}
+}
@@ -0,0 +1,292 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"regexp"
+	"unsafe"
+
+	"github.com/qioalice/devola/core/errors"
+	"github.com/qioalice/devola/core/event"
+	"github.com/qioalice/devola/core/view"
+)
+
+// Group is a sub-registrator which rules are inherit a set of View IDs
+// and which middlewares are applied only to its routes.
+//
+// Use Registrator.Group method to create it and Group.Group method to create
+// a nested one. Nested group inherits View IDs and middlewares of its parent.
+//
+// Group accumulates rules on its own, so each goroutine that registers
+// callbacks concurrently with others should use its own Group.
+//
+// You should use Group the same way as Registrator: accumulate rules using
+// Simple, Complex or pattern methods and flush them by Handler or Middleware.
+// Each accumulated rule's When is extended by group's View IDs.
+//
+// But Middleware called with no accumulated rules registers a group middleware:
+// it will be called for all routes of handlers registered through the group
+// or its nested groups (both already registered and registered later)
+// and never for other routes.
+// Group middlewares are called after the parent group's ones.
+type Group struct {
+
+	// Registrator all callbacks are registered in.
+	r *Registrator
+
+	// Parent group or nil if group is created directly from Registrator.
+	parent *Group
+
+	// View IDs each accumulated rule is extended by
+	// (including parent's View IDs).
+	when []view.ID
+
+	// The group middlewares (without parent's ones)
+	// in the order they has been registered.
+	middlewares []*Callback
+
+	// The routes of handlers registered through the group
+	// or its nested groups.
+	routes []groupRoute
+
+	// The set of rules to which next callback will be applied.
+	// Protected by Registrator's mutex (as all other Group's fields).
+	accumulatedRules []rule
+}
+
+// groupRoute is the handler registered through the Group
+// and the rules it is linked with.
+type groupRoute struct {
+	handler *Callback
+	rules   []rule
+}
+
+// Group returns a new sub-registrator which rules are inherit when View IDs
+// and which middlewares are applied only to its routes.
+func (r *Registrator) Group(when ...view.ID) *Group {
+	return &Group{r: r, when: mergeViewIDs(nil, when)}
+}
+
+// Group returns a new nested group which rules are inherit View IDs
+// of g and when, and which routes are checked by middlewares of g too.
+func (g *Group) Group(when ...view.ID) *Group {
+	return &Group{r: g.r, parent: g, when: mergeViewIDs(g.when, when)}
+}
+
+// Simple is the same as Registrator.Simple but when is extended by
+// group's View IDs.
+func (g *Group) Simple(typ event.Type, when []string) *Group {
+	return g.Complex(typ, string(event.CDataNil), when)
+}
+
+// Complex is the same as Registrator.Complex but when is extended by
+// group's View IDs.
+func (g *Group) Complex(typ event.Type, what string, when []string) *Group {
+	return g.accumulate(makeRule(typ, event.Data(what), g.extend(when)))
+}
+
+// Prefix is the same as Registrator.Prefix but when is extended by
+// group's View IDs.
+func (g *Group) Prefix(typ event.Type, prefix string, when []string) *Group {
+	return g.accumulate(makePatternRule(typ, PatternPrefix, prefix, nil, g.extend(when)))
+}
+
+// Glob is the same as Registrator.Glob but when is extended by
+// group's View IDs.
+func (g *Group) Glob(typ event.Type, glob string, when []string) *Group {
+	return g.accumulate(makePatternRule(typ, PatternGlob, glob, compileGlob(glob), g.extend(when)))
+}
+
+// Regex is the same as Registrator.Regex but when is extended by
+// group's View IDs.
+func (g *Group) Regex(typ event.Type, expr *regexp.Regexp, when []string) *Group {
+	if expr == nil {
+		return g
+	}
+	return g.accumulate(makePatternRule(typ, PatternRegex, expr.String(), expr, g.extend(when)))
+}
+
+// Where is the same as Registrator.Where but adds predicates to the last
+// rule accumulated by g.
+func (g *Group) Where(predicates ...Predicate) *Group {
+	g.r.mu.Lock()
+	addPredicates(g.accumulatedRules, predicates)
+	g.r.mu.Unlock()
+	return g
+}
+
+// Alias is the same as Registrator.Alias but adds aliases to the last
+// rule accumulated by g.
+func (g *Group) Alias(aliases ...string) *Group {
+	g.r.mu.Lock()
+	addAliases(g.accumulatedRules, aliases)
+	g.r.mu.Unlock()
+	return g
+}
+
+// Describe is the same as Registrator.Describe but attaches description
+// to the last rule accumulated by g.
+func (g *Group) Describe(description, usage string, visibleIn ...view.ID) *Group {
+	g.r.mu.Lock()
+	addHelp(g.accumulatedRules, description, usage, visibleIn)
+	g.r.mu.Unlock()
+	return g
+}
+
+// Handler links all accumulated events with passed handler
+// the same way as Registrator.Handler does. Also registers all group
+// middlewares (of g and all its parents) for the same events.
+//
+// If there are no accumulated events, the handler will be registered as
+// main handler (group's View IDs and middlewares are not applied).
+func (g *Group) Handler(handler interface{}) (*Handle, errors.Error) {
+	return g.save(handler, kindHandler)
+}
+
+// Middleware links all accumulated events with passed middleware
+// the same way as Registrator.Middleware does.
+//
+// If there are no accumulated events, the middleware will be registered as
+// group middleware (see Group).
+func (g *Group) Middleware(middleware interface{}) (*Handle, errors.Error) {
+	return g.save(middleware, kindMiddleware)
+}
+
+// Around links all accumulated events with passed around middleware
+// the same way as Registrator.Around does.
+//
+// If there are no accumulated events, the around middleware will be registered
+// as group middleware (see Group).
+func (g *Group) Around(middleware interface{}) (*Handle, errors.Error) {
+	return g.save(middleware, kindAround)
+}
+
+// Fallback is the same as Registrator.Fallback but when is extended by
+// group's View IDs. Thus the handler will be fallback handler of all group's
+// views if when is empty. Group middlewares are not applied.
+func (g *Group) Fallback(handler interface{}, when []string) (*Handle, errors.Error) {
+	c, haveType := g.r.adapt(handler, kindHandler)
+	return g.r.saveFallback(c, haveType, g.extend(when))
+}
+
+// accumulate appends e to the group's accumulated rules.
+func (g *Group) accumulate(e *rule) *Group {
+	g.r.mu.Lock()
+	g.accumulatedRules = append(g.accumulatedRules, *e)
+	g.r.mu.Unlock()
+	return g
+}
+
+// extend returns a set of View IDs: when extended by group's View IDs.
+func (g *Group) extend(when []string) []view.ID {
+	return mergeViewIDs(g.when, *(*[]view.ID)(unsafe.Pointer(&when)))
+}
+
+// save is the Handler and Middleware's core.
+func (g *Group) save(cb interface{}, kind callbackKind) (*Handle, errors.Error) {
+
+	r := g.r
+	isMiddleware := kind.isMiddleware()
+	c, haveType := r.adapt(cb, kind)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rules := g.accumulatedRules
+	if len(rules) == 0 {
+		rules = nil
+	}
+
+	if err := r.checkType(c, haveType, kind, rules); err != nil {
+		return nil, err
+	}
+
+	h := &Handle{r: r, cb: c}
+
+	switch {
+
+	// Group middleware: registered for all already known routes of g,
+	// will be registered for all next ones by handlers registration.
+	case isMiddleware && rules == nil:
+		var regs []*registration
+		for _, route := range g.routes {
+			if !route.handler.isUnregistered {
+				regs = append(regs, r.makeScopedRegistration(c, route.handler, route.rules))
+			}
+		}
+		if err := r.commit(regs...); err != nil {
+			return nil, err
+		}
+		g.middlewares = append(g.middlewares, c)
+		return h, nil
+
+	// Handler: group middlewares are registered for its rules too
+	// and are removed with it.
+	case !isMiddleware && rules != nil:
+		regs := []*registration{r.makeRegistration(c, false, rules)}
+		for _, mw := range g.allMiddlewares() {
+			regs = append(regs, r.makeScopedRegistration(mw, c, rules))
+		}
+		if err := r.commit(regs...); err != nil {
+			return nil, err
+		}
+		for gg := g; gg != nil; gg = gg.parent {
+			gg.routes = append(gg.routes, groupRoute{c, regs[0].rules})
+		}
+
+	default:
+		if err := r.commit(r.makeRegistration(c, isMiddleware, rules)); err != nil {
+			return nil, err
+		}
+	}
+
+	g.accumulatedRules = nil
+	return h, nil
+}
+
+// allMiddlewares returns all alive group middlewares of g and its parents,
+// the parent's ones first.
+func (g *Group) allMiddlewares() []*Callback {
+
+	var middlewares []*Callback
+	if g.parent != nil {
+		middlewares = g.parent.allMiddlewares()
+	}
+
+	for _, mw := range g.middlewares {
+		if !mw.isUnregistered {
+			middlewares = append(middlewares, mw)
+		}
+	}
+
+	return middlewares
+}
+
+// mergeViewIDs returns a new slice with all View IDs of a and then
+// all View IDs of b that are not presented in a.
+func mergeViewIDs(a, b []view.ID) []view.ID {
+
+	merged := make([]view.ID, 0, len(a)+len(b))
+	merged = append(merged, a...)
+
+	for _, id := range b {
+		found := false
+		for _, id2 := range merged {
+			if found = id == id2; found {
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, id)
+		}
+	}
+
+	if len(merged) == 0 {
+		return nil
+	}
+
+	return merged
+}
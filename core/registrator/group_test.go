@@ -0,0 +1,104 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/qioalice/devola/core/ctx"
+)
+
+// groupRecorder registers group middlewares that record their names
+// and returns the names of middlewares that are called for an event.
+type groupRecorder struct {
+	t     *testing.T
+	r     *Registrator
+	calls []string
+}
+
+// middleware registers group middleware of g which records name.
+func (gr *groupRecorder) middleware(g *Group, name string) *Handle {
+	h, err := g.Middleware(func(*ctx.BaseCtx) bool {
+		gr.calls = append(gr.calls, name)
+		return true
+	})
+	if err != nil {
+		gr.t.Fatalf("Middleware %s: unexpected error: %v", name, err)
+	}
+	return h
+}
+
+// handler registers handler for the rule accumulated by g.
+func (gr *groupRecorder) handler(g *Group) *Handle {
+	h, err := g.Handler(func(*ctx.BaseCtx) {})
+	if err != nil {
+		gr.t.Fatalf("Handler: unexpected error: %v", err)
+	}
+	return h
+}
+
+// called returns the names of middlewares matched for the command data.
+func (gr *groupRecorder) called(data string) []string {
+	gr.calls = nil
+	c := makeTestCtx(testTypeCommand, data)
+	for _, mw := range gr.r.MatchCtx(c, true) {
+		mw.Call(unsafe.Pointer(c))
+	}
+	return gr.calls
+}
+
+func TestGroupMiddlewaresScope(t *testing.T) {
+
+	r := makeTestRegistrator()
+	gr := &groupRecorder{t: t, r: r}
+
+	a, b, plain := r.Group(), r.Group(), r.Group()
+	nested := b.Group()
+
+	gr.middleware(a, "a")
+	gr.handler(a.Complex(testTypeCommand, "/buy", nil))
+
+	gr.handler(plain.Complex(testTypeCommand, "/bye", nil))
+
+	gr.handler(b.Prefix(testTypeCommand, "/b", nil))
+	gr.middleware(b, "b") // registered for already known routes
+
+	hNested := gr.handler(nested.Complex(testTypeCommand, "/bar", nil))
+	gr.middleware(nested, "nested")
+
+	tests := []struct {
+		data string
+		want []string
+	}{
+		{"/buy", []string{"a"}},
+		{"/bye", nil},
+		{"/bz", []string{"b"}},
+		{"/bar", []string{"b", "nested"}},
+	}
+
+	for _, tt := range tests {
+		if got := gr.called(tt.data); !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("%s: got middlewares %v, want %v", tt.data, got, tt.want)
+		}
+	}
+
+	if ok, err := hNested.Unregister(); !ok || err != nil {
+		t.Fatalf("Unregister: got %v, %v, want true, nil", ok, err)
+	}
+
+	// Group middlewares registered for "/bar" are removed with its handler,
+	// so the route is handled by prefix "/b" one.
+	if got, want := gr.called("/bar"), []string{"b"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("/bar after Unregister: got middlewares %v, want %v", got, want)
+	}
+	for _, reg := range r.current().registrations {
+		if reg.scope == hNested.cb {
+			t.Fatalf("registration of group middleware %s is left after Unregister", reg.cb.Name())
+		}
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"github.com/qioalice/devola/core/errors"
+)
+
+// Handle represents a result of one successful registration of handler
+// or middleware in Registrator.
+//
+// Keep it if you want to unregister exactly those callback later
+// (feature toggles, plugins that can be disabled, etc).
+// It's safe to unregister callback while Registrator is used
+// to match callbacks by another goroutines.
+type Handle struct {
+
+	// Registrator the callback has been registered in.
+	r *Registrator
+
+	// Registered callback this handle is associated with.
+	// All registrations of cb (and of group middlewares registered
+	// for cb's routes) are removed by Unregister.
+	cb *Callback
+}
+
+// Unregister removes callback h is associated with from all rules
+// it has been linked with. Returns false if h is nil or already unregistered.
+// Returns false and an error with ECFrozen code if Registrator is frozen.
+func (h *Handle) Unregister() (bool, errors.Error) {
+	if h == nil {
+		return false, nil
+	}
+	return h.r.Unregister(h)
+}
+
+// IsMiddleware reports whether h is associated with middleware (not handler).
+func (h *Handle) IsMiddleware() bool {
+	return h != nil && h.cb.IsMiddleware()
+}
@@ -0,0 +1,141 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"github.com/qioalice/devola/core/command"
+	"github.com/qioalice/devola/core/errors"
+	"github.com/qioalice/devola/core/event"
+	"github.com/qioalice/devola/core/view"
+)
+
+// Help is a description of rule that is used to generate help message
+// and command menu (see Registrator.Commands).
+type Help struct {
+
+	// Short description of what the command does.
+	Description string `json:"description"`
+
+	// How to use the command (like "/echo <text>").
+	// The command itself is used if it's empty.
+	Usage string `json:"usage,omitempty"`
+
+	// The views the command is shown in.
+	// If empty, the command is shown in all views it is registered for.
+	Views []view.ID `json:"views,omitempty"`
+}
+
+// Command is one entry of command menu (see command.Command).
+type Command = command.Command
+
+// Commands is a command menu (see command.Commands).
+// It's declared in a neutral package, so backends and bridge
+// don't depend on registrator.
+type Commands = command.Commands
+
+// Translator is an alias to function that translates the text of help
+// message (description or usage) to some language.
+type Translator func(text string) string
+
+// Describe attaches description and usage to the last accumulated rule
+// (by Simple, Complex or pattern methods). Thus the rule will be shown
+// in command menu and help message generated by Commands method.
+// If visibleIn is not empty, the rule is shown only in these views.
+// Does nothing if there is no accumulated rules.
+//
+//	r.Complex(command, "echo", nil).Describe("Repeats the text", "/echo <text>").Handler(h)
+func (r *Registrator) Describe(description, usage string, visibleIn ...view.ID) *Registrator {
+	r.mu.Lock()
+	addHelp(r.accumulatedRules, description, usage, visibleIn)
+	r.mu.Unlock()
+	return r
+}
+
+// addHelp attaches a new Help object to the last rule of rules (if any).
+func addHelp(rules []rule, description, usage string, visibleIn []view.ID) {
+	if n := len(rules); n != 0 {
+		rules[n-1].Help = &Help{
+			Description: description,
+			Usage:       usage,
+			Views:       visibleIn,
+		}
+	}
+}
+
+// Commands returns a command menu for viewID: all described exact rules
+// of handlers with event type typ that are registered for viewID
+// or without View ID and are visible in viewID, in the order they has been
+// registered. Descriptions and usages are translated by tr (if it's not nil).
+//
+// typ is the type of events that are considered commands by your backend.
+func (r *Registrator) Commands(typ event.Type, viewID view.IDEnc, tr Translator) Commands {
+
+	// Sessions can keep encoded View IDs of renamed views.
+	viewID = r.converter.Canonical(viewID)
+
+	var id view.ID
+	if viewID != view.CIDEncNil {
+		var code errors.Code
+		if id, code = r.converter.Decode(viewID); code != errors.ECOK {
+			id = view.CIDNil
+		}
+	}
+
+	var commands Commands
+	seen := make(map[event.Data]struct{})
+
+	for _, reg := range r.current().registrations {
+		if reg.isMiddleware || reg.isFallback {
+			continue
+		}
+		for i := range reg.targets {
+			t := &reg.targets[i]
+			help := t.rule.Help
+
+			if help == nil || t.rule.Type != typ || t.rule.Pattern != PatternExact ||
+				t.data != t.rule.Data || !help.isVisibleIn(id, r.converter.Resolve) ||
+				t.viewID != view.CIDEncNil && t.viewID != viewID {
+				continue
+			}
+
+			if _, found := seen[t.data]; found {
+				continue
+			}
+			seen[t.data] = struct{}{}
+
+			command := Command{
+				Command:     string(t.data),
+				Description: help.Description,
+				Usage:       help.Usage,
+			}
+			if tr != nil {
+				command.Description = tr(command.Description)
+				if command.Usage != "" {
+					command.Usage = tr(command.Usage)
+				}
+			}
+
+			commands = append(commands, command)
+		}
+	}
+
+	return commands
+}
+
+// isVisibleIn reports whether the rule h is attached to must be shown in id
+// (view.CIDNil if there is no View ID). Views of h are compared with id
+// after resolving (so renamed views are still matched by their old names).
+func (h *Help) isVisibleIn(id view.ID, resolve func(view.ID) view.ID) bool {
+	if len(h.Views) == 0 {
+		return true
+	}
+	for _, visibleIn := range h.Views {
+		if resolve(visibleIn) == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"github.com/qioalice/devola/core/event"
+	"github.com/qioalice/devola/core/view"
+)
+
+// patternIndex is a prefix tree of pattern slots registered for the same
+// event type, View ID encoded and callback kind. It is built by Freeze.
+//
+// Each slot is saved to the node of its literal prefix: the part of event data
+// each matched data starts with (prefix itself, the part of glob before
+// the first wildcard, the literal prefix of regex).
+// So only slots which literal prefix is a prefix of occurred event's data
+// are checked instead of all pattern slots of event type.
+type patternIndex struct {
+	root patternNode
+}
+
+// patternNode is a node of patternIndex.
+type patternNode struct {
+	slots    []indexedSlot
+	children map[byte]*patternNode
+}
+
+// indexedSlot is a pattern slot with its position in the storage's
+// pattern section. Slots must be checked in the order they has been registered.
+type indexedSlot struct {
+	order int
+	slot  *patternSlot
+}
+
+// patternIndexKey is a key of storage's pattern indexes.
+type patternIndexKey struct {
+	typ          event.Type
+	viewID       view.IDEnc
+	isMiddleware bool
+}
+
+// indexPatterns builds pattern indexes of all pattern slots of s.
+// Returns nil if there is no pattern slots.
+func (s *storage) indexPatterns() map[patternIndexKey]*patternIndex {
+
+	var indexes map[patternIndexKey]*patternIndex
+
+	for _, isMiddleware := range [2]bool{false, true} {
+
+		sections := s.handlersPattern
+		if isMiddleware {
+			sections = s.middlewaresPattern
+		}
+
+		for typ, slots := range sections {
+			for i, slot := range slots {
+
+				key := patternIndexKey{typ, slot.viewID, isMiddleware}
+
+				if indexes == nil {
+					indexes = make(map[patternIndexKey]*patternIndex)
+				}
+				if indexes[key] == nil {
+					indexes[key] = new(patternIndex)
+				}
+
+				indexes[key].add(i, slot)
+			}
+		}
+	}
+
+	return indexes
+}
+
+// add saves slot which position in its pattern section is order
+// to the node of slot's literal prefix.
+func (idx *patternIndex) add(order int, slot *patternSlot) {
+
+	node := &idx.root
+
+	for _, c := range []byte(slot.literalPrefix()) {
+		if node.children == nil {
+			node.children = make(map[byte]*patternNode)
+		}
+		child := node.children[c]
+		if child == nil {
+			child = new(patternNode)
+			node.children[c] = child
+		}
+		node = child
+	}
+
+	node.slots = append(node.slots, indexedSlot{order, slot})
+}
+
+// candidates appends to buf all slots which literal prefix is a prefix
+// of data in the order they has been registered and returns it.
+func (idx *patternIndex) candidates(data event.Data, buf []indexedSlot) []indexedSlot {
+
+	node := &idx.root
+	buf = append(buf, node.slots...)
+
+	for i := 0; i < len(data) && node.children != nil; i++ {
+		if node = node.children[data[i]]; node == nil {
+			break
+		}
+		buf = append(buf, node.slots...)
+	}
+
+	// Slots of each node are already ordered, and there are only a few
+	// nodes with slots on the path, so insertion sort is enough.
+	for i := 1; i < len(buf); i++ {
+		for j := i; j > 0 && buf[j].order < buf[j-1].order; j-- {
+			buf[j], buf[j-1] = buf[j-1], buf[j]
+		}
+	}
+
+	return buf
+}
+
+// literalPrefix returns the part of event data each data matched by ps
+// starts with. Returns an empty string for case folded prefixes,
+// because they are compared case-insensitively.
+func (ps *patternSlot) literalPrefix() string {
+	switch {
+	case ps.kind == PatternPrefix && !ps.fold:
+		return string(ps.source)
+	case ps.re != nil:
+		prefix, _ := ps.re.LiteralPrefix()
+		return prefix
+	}
+	return ""
+}
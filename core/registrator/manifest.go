@@ -0,0 +1,317 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"unsafe"
+
+	"github.com/qioalice/devola/core/errors"
+	"github.com/qioalice/devola/core/event"
+	"github.com/qioalice/devola/core/sys/fn"
+	"github.com/qioalice/devola/core/view"
+)
+
+// Manifest is a declarative description of routes: what handlers and
+// middlewares must be called for what events in what views.
+//
+// Callbacks are referred by their names in fn.Registry, so the layout
+// of commands and buttons can be changed without changing Go code.
+// Use Registrator.LoadManifest or Registrator.LoadManifestJSON to apply it.
+//
+// YAML is deliberately not supported: the core doesn't depend on any
+// YAML library. Convert YAML manifest to JSON (e.g. by sigs.k8s.io/yaml's
+// YAMLToJSON) and use LoadManifestJSON, or decode it to Manifest by yourself.
+//
+// JSON example:
+//
+//	{"routes": [
+//	    {"type": "command", "data": "start", "handler": "start"},
+//	    {"type": "button", "data": "Buy", "when": ["catalog"],
+//	        "middlewares": ["auth"], "handler": "buy"},
+//	    {"type": "button", "data": "order/{id}", "pattern": "glob",
+//	        "handler": "order"},
+//	    {"when": ["catalog"], "handler": "catalogFallback"},
+//	    {"handler": "notFound"}
+//	]}
+type Manifest struct {
+	Routes []ManifestRoute `json:"routes"`
+}
+
+// ManifestRoute is one route of Manifest.
+//
+// If Type is not empty, all middlewares and then handler are registered
+// for the rule described by Type, Data, Pattern and When
+// (the same as Complex or pattern methods and then Middleware, Handler do).
+//
+// If Type is empty and When is not, the handler is registered
+// as fallback handler of When views (see Registrator.Fallback).
+// If both of Type and When are empty, the handler and middlewares are
+// registered as main ones.
+type ManifestRoute struct {
+
+	// The event type: its comment (see event.TypeComment) or its number.
+	Type string `json:"type,omitempty"`
+
+	// The event data or pattern (depends on Pattern).
+	Data string `json:"data,omitempty"`
+
+	// Additional event data (see Registrator.Alias).
+	Aliases []string `json:"aliases,omitempty"`
+
+	// Description of route for help message and command menu
+	// (see Registrator.Describe).
+	Help *Help `json:"help,omitempty"`
+
+	// The way using which Data is compared with the data of occurred event.
+	Pattern PatternKind `json:"pattern,omitempty"`
+
+	// View IDs the route is registered for.
+	// All of them must be already registered in View ID converter.
+	When []string `json:"when,omitempty"`
+
+	// The names of middlewares in fn.Registry in the order they will be called.
+	// Around middlewares are detected by their types.
+	Middlewares []string `json:"middlewares,omitempty"`
+
+	// The name of handler in fn.Registry.
+	Handler string `json:"handler,omitempty"`
+}
+
+// manifestStep is one registration the manifest's route consists of.
+type manifestStep struct {
+	cb       *Callback
+	haveType reflect.Type
+	kind     callbackKind
+
+	// The rules cb will be linked with. Nil for main callbacks.
+	rules []rule
+
+	// View IDs cb will be fallback handler of. Nil for other callbacks.
+	fallback []view.ID
+}
+
+// LoadManifestJSON decodes the routes manifest from JSON data
+// and loads it to r (see LoadManifest).
+//
+// Returns a not nil EBadManifest error object with ManifestSyntax problem
+// if data is not a valid JSON or has unknown fields.
+func (r *Registrator) LoadManifestJSON(data []byte, registry fn.Registry) ([]*Handle, errors.Error) {
+
+	var m Manifest
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&m); err != nil {
+		return nil, makeEBadManifest(ManifestSyntax, -1, err.Error())
+	}
+
+	return r.LoadManifest(&m, registry)
+}
+
+// LoadManifest registers all routes of m, taking callbacks by their names
+// from registry.
+//
+// All routes are checked before the registration. If some callback is missing
+// or has incompatible type, or some view or event type is unknown,
+// nothing is registered and a not nil EBadManifest error object is returned.
+// If registration itself is failed (strict mode conflicts, frozen Registrator),
+// all already registered routes of m are removed and the error is returned.
+//
+// Returns the Handles of all registrations in the order they has been made
+// (middlewares of route first).
+// Accumulated events are not used and are not cleared.
+func (r *Registrator) LoadManifest(m *Manifest, registry fn.Registry) ([]*Handle, errors.Error) {
+
+	if m == nil {
+		return nil, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	steps, err := r.prepareManifest(m, registry)
+	if err != nil {
+		return nil, err
+	}
+
+	before := r.current().registrations
+	handles := make([]*Handle, 0, len(steps))
+
+	for _, step := range steps {
+
+		var h *Handle
+		if step.fallback != nil {
+			h, err = r.registerFallback(step.cb, step.haveType, step.fallback)
+		} else {
+			h, err = r.register(step.cb, step.haveType, step.kind, step.rules)
+		}
+
+		if err != nil {
+			if len(handles) != 0 {
+				for _, h := range handles {
+					h.cb.isUnregistered = true
+				}
+				r.storage.Store(r.makeStorage(before))
+			}
+			return nil, err
+		}
+
+		handles = append(handles, h)
+	}
+
+	return handles, nil
+}
+
+// prepareManifest checks all routes of m and converts them to the registrations
+// that must be made.
+// r.mu must be locked.
+func (r *Registrator) prepareManifest(m *Manifest, registry fn.Registry) ([]manifestStep, errors.Error) {
+
+	var steps []manifestStep
+
+	for i := range m.Routes {
+		route := &m.Routes[i]
+
+		when, err := r.manifestViews(i, route.When)
+		if err != nil {
+			return nil, err
+		}
+
+		var rules []rule
+
+		switch {
+		case route.Type != "":
+			typ := event.ParseType(route.Type)
+			if typ == event.CTypeInvalid {
+				return nil, makeEBadManifest(ManifestUnknownType, i, route.Type)
+			}
+			e, err := makeManifestRule(i, typ, route, when)
+			if err != nil {
+				return nil, err
+			}
+			rules = []rule{*e}
+			addAliases(rules, route.Aliases)
+			rules[0].Help = route.Help
+
+		case route.Handler == "" && len(route.Middlewares) == 0:
+			return nil, makeEBadManifest(ManifestBadRoute, i, "nothing to register")
+
+		case len(when) != 0 && len(route.Middlewares) != 0:
+			return nil, makeEBadManifest(ManifestBadRoute, i, "middlewares can not be applied to fallback handler")
+		}
+
+		for _, name := range route.Middlewares {
+			step, err := r.manifestStep(i, registry, name, true)
+			if err != nil {
+				return nil, err
+			}
+			step.rules = rules
+			steps = append(steps, step)
+		}
+
+		if route.Handler == "" {
+			continue
+		}
+
+		step, err := r.manifestStep(i, registry, route.Handler, false)
+		if err != nil {
+			return nil, err
+		}
+
+		if rules == nil && len(when) != 0 {
+			step.fallback = when
+		} else {
+			step.rules = rules
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps, nil
+}
+
+// manifestViews returns when as View IDs or an error if some of them
+// is invalid or not registered in View ID converter.
+// i is an index of route when belongs to.
+func (r *Registrator) manifestViews(i int, when []string) ([]view.ID, errors.Error) {
+
+	// []string -> []view.ID conversion without memory reallocation
+	ids := *(*[]view.ID)(unsafe.Pointer(&when))
+
+	for _, id := range ids {
+		if !id.IsValid() || !r.converter.Has(id) {
+			return nil, makeEBadManifest(ManifestUnknownView, i, string(id))
+		}
+	}
+
+	return ids, nil
+}
+
+// manifestStep returns a registration of callback with passed name
+// or an error if there is no such callback in registry or it has incompatible
+// type. Middleware is considered around middleware if it has the type
+// around middlewares must have.
+// i is an index of route callback belongs to.
+// r.mu must be locked.
+func (r *Registrator) manifestStep(i int, registry fn.Registry, name string, isMiddleware bool) (manifestStep, errors.Error) {
+
+	named, found := registry.Get(name)
+	if !found {
+		return manifestStep{}, makeEBadManifest(ManifestMissingCallback, i, name)
+	}
+
+	kind := kindHandler
+	if isMiddleware {
+		kind = kindMiddleware
+		if named.Type != nil && named.Type == r.aroundTypeRequired {
+			kind = kindAround
+		}
+	}
+
+	if wantType := r.requiredType(kind); named.Type != wantType {
+		e := makeEBadManifest(ManifestBadCallback, i, name)
+		e.WantType, e.HaveType = typeString(wantType), typeString(named.Type)
+		return manifestStep{}, e
+	}
+
+	return manifestStep{
+		cb:       makeCallbackNamed(named, kind),
+		haveType: named.Type,
+		kind:     kind,
+	}, nil
+}
+
+// makeManifestRule creates a new rule object of route with already parsed
+// event type and View IDs.
+// i is an index of route.
+func makeManifestRule(i int, typ event.Type, route *ManifestRoute, when []view.ID) (*rule, errors.Error) {
+
+	switch route.Pattern {
+
+	case PatternExact:
+		return makeRule(typ, event.Data(route.Data), when), nil
+
+	case PatternPrefix:
+		return makePatternRule(typ, PatternPrefix, route.Data, nil, when), nil
+
+	case PatternGlob:
+		return makePatternRule(typ, PatternGlob, route.Data, compileGlob(route.Data), when), nil
+
+	case PatternRegex:
+		re, err := regexp.Compile(route.Data)
+		if err != nil {
+			return nil, makeEBadManifest(ManifestBadRoute, i, err.Error())
+		}
+		return makePatternRule(typ, PatternRegex, route.Data, re, when), nil
+	}
+
+	return nil, makeEBadManifest(ManifestBadRoute, i, "unknown pattern kind "+route.Pattern.String())
+}
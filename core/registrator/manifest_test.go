@@ -0,0 +1,88 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"testing"
+
+	"github.com/qioalice/devola/core/ctx"
+	"github.com/qioalice/devola/core/event"
+	"github.com/qioalice/devola/core/sys/fn"
+	"github.com/qioalice/devola/core/view"
+)
+
+func TestLoadManifestJSON(t *testing.T) {
+
+	r := makeTestRegistrator()
+	registry := fn.MakeRegistry(
+		fn.MakeNamed("start", func(*ctx.BaseCtx) {}),
+		fn.MakeNamed("auth", func(*ctx.BaseCtx) bool { return true }),
+	)
+
+	handles, err := r.LoadManifestJSON([]byte(`{"routes": [
+		{"type": "1", "data": "/start", "middlewares": ["auth"], "handler": "start"},
+		{"type": "1", "data": "/order ", "pattern": "prefix", "handler": "start"}
+	]}`), registry)
+	if err != nil {
+		t.Fatalf("LoadManifestJSON: unexpected error: %v", err)
+	}
+	if len(handles) != 3 {
+		t.Fatalf("got %d handles, want 3", len(handles))
+	}
+
+	if len(r.Match(testTypeCommand, "/start", view.CIDEncNil, false)) != 1 ||
+		len(r.Match(testTypeCommand, "/start", view.CIDEncNil, true)) != 1 {
+		t.Fatalf("/start: handler or middleware is not matched")
+	}
+	if len(r.Match(testTypeCommand, "/order 42", view.CIDEncNil, false)) != 1 {
+		t.Fatalf("/order 42: handler is not matched")
+	}
+
+	if _, err := r.LoadManifestJSON([]byte(`{"routes": [], "unknown": 1}`), registry); err == nil {
+		t.Fatalf("LoadManifestJSON of unknown field: no error")
+	}
+}
+
+// The routes of manifest registered before the failed one must be removed.
+func TestLoadManifestRollback(t *testing.T) {
+
+	r := makeTestRegistrator(ParamStrict(true))
+	registry := fn.MakeRegistry(fn.MakeNamed("h", func(*ctx.BaseCtx) {}))
+
+	if _, err := r.Complex(testTypeCommand, "/help", nil).RegisterHandler(func(*ctx.BaseCtx) {}); err != nil {
+		t.Fatalf("RegisterHandler: unexpected error: %v", err)
+	}
+	before := r.Routes()
+
+	handles, err := r.LoadManifest(&Manifest{Routes: []ManifestRoute{
+		{Type: "1", Data: "/start", Handler: "h"},
+		{Type: "1", Data: "/about", Handler: "h"},
+		{Type: "1", Data: "/help", Handler: "h"}, // conflicts in strict mode
+	}}, registry)
+
+	if err == nil || err.Code() != ECRoutesConflict {
+		t.Fatalf("LoadManifest: got error %v, want conflict", err)
+	}
+	if handles != nil {
+		t.Fatalf("LoadManifest: got %d handles, want nil", len(handles))
+	}
+
+	if after := r.Routes(); len(after) != len(before) {
+		t.Fatalf("got %d routes after rollback, want %d", len(after), len(before))
+	}
+	for _, data := range []event.Data{"/start", "/about"} {
+		if len(r.Match(testTypeCommand, data, view.CIDEncNil, false)) != 0 {
+			t.Fatalf("%s: route of failed manifest is matched", data)
+		}
+	}
+
+	r.Freeze()
+	if _, err := r.LoadManifest(&Manifest{Routes: []ManifestRoute{
+		{Type: "1", Data: "/start", Handler: "h"},
+	}}, registry); err == nil || err.Code() != ECFrozen {
+		t.Fatalf("LoadManifest to frozen: got error %v, want ECFrozen", err)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"github.com/qioalice/devola/core/ctx"
+	"github.com/qioalice/devola/core/event"
+)
+
+// param is an alias to function that takes a Registrator object and changes
+// its behaviour.
+// It uses as parameters for Registrator constructor.
+type param func(r *Registrator)
+
+// ParamStrict enables (or disables) the strict mode of Registrator.
+//
+// In strict mode each registration of handler or middleware is checked
+// whether its routes are duplicated or shadowed by already registered ones.
+// If it's so, the registration is rejected and EConflict is returned.
+//
+// Middlewares without handlers can not be detected at the registration time,
+// use Registrator.Check method for that after all callbacks are registered.
+func ParamStrict(enable bool) param {
+	return func(r *Registrator) { r.isStrict = enable }
+}
+
+// ParamCaseFolding makes event data of passed types comparing
+// case-insensitively (e.g. commands "/start", "/Start" and "/START"
+// are the same). Event data of other types is compared as is.
+//
+// Affects exact and prefix rules (and their aliases) and glob rules.
+// Regex rules are not affected, use "(?i)" flag for them.
+func ParamCaseFolding(types ...event.Type) param {
+	return func(r *Registrator) {
+		for _, typ := range types {
+			r.caseFolded[typ] = true
+		}
+	}
+}
+
+// ParamSuggestions sets the hook that is called by Registrator.MatchCtx
+// if there is no route for occurred event (only fallback or main handlers
+// can be called). The hook takes the context of event and up to max closest
+// registered event data (see Registrator.Suggest), so it can prepare
+// an "unknown command, did you mean ..." reply for fallback handler.
+//
+// The hook is not called for "simple" event types.
+func ParamSuggestions(max int, hook func(c *ctx.BaseCtx, suggestions []event.Data)) param {
+	return func(r *Registrator) { r.suggestionsMax, r.onNotFound = max, hook }
+}
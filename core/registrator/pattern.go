@@ -0,0 +1,215 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/qioalice/devola/core/ctx"
+	"github.com/qioalice/devola/core/event"
+	"github.com/qioalice/devola/core/view"
+)
+
+// PatternKind represents the way using which the event data of rule
+// is compared with the data of occurred event.
+type PatternKind uint8
+
+// Predefined pattern kinds.
+const (
+
+	// Event data of rule must be exactly the same as occurred event's data.
+	// It is the default kind and the fastest one (only map lookup is used).
+	PatternExact PatternKind = 0
+
+	// Occurred event's data must starts with event data of rule.
+	// The rest part of occurred event's data is captured
+	// as event.CCaptureRest.
+	PatternPrefix PatternKind = 1
+
+	// Event data of rule is a glob that occurred event's data must match.
+	// Supported:
+	// "*" - any sequence of chars (including empty),
+	// "?" - any single char,
+	// "{name}" - any sequence of chars that will be captured as "name",
+	// "\" - escapes the next char.
+	PatternGlob PatternKind = 2
+
+	// Event data of rule is a regular expression that occurred event's data
+	// must match entirely (the expression is anchored at both ends).
+	// All named groups are captured by their names.
+	PatternRegex PatternKind = 3
+)
+
+// String returns a string representation of pattern kind.
+func (k PatternKind) String() string {
+	switch k {
+	case PatternExact:
+		return "Exact"
+	case PatternPrefix:
+		return "Prefix"
+	case PatternGlob:
+		return "Glob"
+	case PatternRegex:
+		return "Regex"
+	}
+	return "Unknown"
+}
+
+// MarshalText implements encoding.TextMarshaler interface.
+// Allows to represent pattern kind in JSON by its string representation.
+func (k PatternKind) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(k.String())), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler interface.
+// Allows to read pattern kind from JSON by its string representation
+// (case insensitive). Empty string is PatternExact.
+func (k *PatternKind) UnmarshalText(text []byte) error {
+	for _, kind := range [...]PatternKind{PatternExact, PatternPrefix, PatternGlob, PatternRegex} {
+		if strings.EqualFold(string(text), kind.String()) {
+			*k = kind
+			return nil
+		}
+	}
+	if len(text) == 0 {
+		*k = PatternExact
+		return nil
+	}
+	return fmt.Errorf("unknown pattern kind %q", text)
+}
+
+// patternSlot is an entry of pattern storage section in Registrator.
+// Keeps one pattern, View ID encoded it is registered for and all callbacks
+// that are linked with that pair.
+type patternSlot struct {
+	kind   PatternKind
+	source event.Data
+	re     *regexp.Regexp
+	fold   bool
+	viewID view.IDEnc
+	cbs    []*Callback
+}
+
+// match reports whether data matches the pattern of ps and returns
+// all captured parts of data (or nil if there is nothing to capture).
+func (ps *patternSlot) match(data event.Data) (event.Captures, bool) {
+
+	switch ps.kind {
+
+	case PatternPrefix:
+		switch {
+		case len(data) < len(ps.source):
+			return nil, false
+		case ps.fold && !strings.EqualFold(string(data[:len(ps.source)]), string(ps.source)):
+			return nil, false
+		case !ps.fold && data[:len(ps.source)] != ps.source:
+			return nil, false
+		}
+		rest := data[len(ps.source):]
+		if rest == event.CDataNil {
+			return nil, true
+		}
+		return event.Captures{event.CCaptureRest: string(rest)}, true
+
+	case PatternGlob, PatternRegex:
+		submatches := ps.re.FindStringSubmatch(string(data))
+		if submatches == nil {
+			return nil, false
+		}
+		var captures event.Captures
+		for i, name := range ps.re.SubexpNames() {
+			if i == 0 || name == "" {
+				continue
+			}
+			if captures == nil {
+				captures = make(event.Captures)
+			}
+			captures[name] = submatches[i]
+		}
+		return captures, true
+	}
+
+	return nil, false
+}
+
+// matchCallbacks returns callbacks of ps which are allowed by c and handlers
+// (see filter) and captures if data matches the pattern of ps.
+func (ps *patternSlot) matchCallbacks(c *ctx.BaseCtx, handlers []*Callback, data event.Data) ([]*Callback, event.Captures) {
+	captures, matched := ps.match(data)
+	if !matched {
+		return nil, nil
+	}
+	return filter(ps.cbs, c, handlers), captures
+}
+
+// compileGlob converts glob to the anchored regular expression
+// (see PatternGlob for supported syntax).
+//
+// Unclosed or empty "{}" are treated literally.
+func compileGlob(glob string) *regexp.Regexp {
+
+	var b strings.Builder
+	b.WriteString(`(?s)^`)
+
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+
+		case '*':
+			b.WriteString(`.*?`)
+
+		case '?':
+			b.WriteString(`.`)
+
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+			}
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+
+		case '{':
+			end := strings.IndexByte(glob[i:], '}')
+			name := ""
+			if end > 1 {
+				name = glob[i+1 : i+end]
+			}
+			if !isCaptureName(name) {
+				b.WriteString(regexp.QuoteMeta("{"))
+				continue
+			}
+			b.WriteString(`(?P<` + name + `>.*?)`)
+			i += end
+
+		default:
+			b.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+
+	b.WriteString(`$`)
+	return regexp.MustCompile(b.String())
+}
+
+// anchorRegex returns re anchored at both ends, so it matches only
+// the whole event data (as glob and prefix patterns do).
+func anchorRegex(re *regexp.Regexp) *regexp.Regexp {
+	return regexp.MustCompile(`^(?:` + re.String() + `)$`)
+}
+
+// isCaptureName reports whether name can be used as the name of regexp group.
+func isCaptureName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c != '_' && (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') &&
+			(i == 0 || c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/qioalice/devola/core/ctx"
+	"github.com/qioalice/devola/core/event"
+	"github.com/qioalice/devola/core/view"
+)
+
+func TestPatterns(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		register func(r *Registrator) *Registrator
+		data     string
+		matched  bool
+		captures event.Captures
+	}{
+		{"prefix/exact", prefix("/buy"), "/buy", true, nil},
+		{"prefix/rest", prefix("/buy"), "/buy 42", true, event.Captures{event.CCaptureRest: " 42"}},
+		{"prefix/shorter", prefix("/buy"), "/bu", false, nil},
+		{"prefix/not prefix", prefix("/buy"), "x/buy", false, nil},
+
+		{"glob/star", glob("order/*"), "order/42", true, nil},
+		{"glob/question", glob("page?"), "page1", true, nil},
+		{"glob/question too long", glob("page?"), "page12", false, nil},
+		{"glob/captures", glob("order/{id}/{action}"), "order/42/edit",
+			true, event.Captures{"id": "42", "action": "edit"}},
+		{"glob/escape", glob(`a\*b`), "a*b", true, nil},
+		{"glob/escape literal", glob(`a\*b`), "axb", false, nil},
+		{"glob/anchored start", glob("order/*"), "x/order/42", false, nil},
+
+		{"regex/exact", regex("/buy"), "/buy", true, nil},
+		{"regex/anchored start", regex("/buy"), "x/buy", false, nil},
+		{"regex/anchored end", regex("/buy"), "/buyy", false, nil},
+		{"regex/alternation anchored", regex("/a|/b"), "/bx", false, nil},
+		{"regex/captures", regex(`/buy (?P<count>\d+) (?P<item>\w+)`), "/buy 2 apples",
+			true, event.Captures{"count": "2", "item": "apples"}},
+		{"regex/no match", regex(`/buy (?P<count>\d+)`), "/buy x", false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			r := makeTestRegistrator()
+			if err := tt.register(r).Handler(func(*ctx.BaseCtx) {}); err != nil {
+				t.Fatalf("Handler: unexpected error: %v", err)
+			}
+
+			for _, isFrozen := range []bool{false, true} {
+				if isFrozen {
+					r.Freeze()
+				}
+
+				c := makeTestCtx(testTypeCommand, tt.data)
+				handlers := r.MatchCtx(c, false)
+
+				if matched := len(handlers) != 0; matched != tt.matched {
+					t.Fatalf("data %q, frozen %v: matched %v, want %v", tt.data, isFrozen, matched, tt.matched)
+				}
+				if !reflect.DeepEqual(c.Event.Captures, tt.captures) {
+					t.Fatalf("data %q, frozen %v: captures %v, want %v", tt.data, isFrozen, c.Event.Captures, tt.captures)
+				}
+			}
+		})
+	}
+}
+
+// Frozen Registrator must check overlapping patterns in the same order
+// as not frozen one: the first registered matched pattern wins.
+func TestFrozenPatternsOrder(t *testing.T) {
+
+	patterns := []func(r *Registrator) *Registrator{
+		prefix("/buy"), glob("/b*"), prefix("/b"), regex(`/bu.*`), glob("*"), prefix("/buy "),
+	}
+	data := []event.Data{"/buy", "/buy 42", "/bx", "/bu", "/", "x", "/b"}
+
+	for _, reversed := range []bool{false, true} {
+
+		r := makeTestRegistrator()
+		for i := range patterns {
+			register := patterns[i]
+			if reversed {
+				register = patterns[len(patterns)-1-i]
+			}
+			if err := register(r).Handler(func(*ctx.BaseCtx) {}); err != nil {
+				t.Fatalf("Handler: unexpected error: %v", err)
+			}
+		}
+
+		want := make([][]*Callback, len(data))
+		for i, d := range data {
+			want[i] = r.Match(testTypeCommand, d, view.CIDEncNil, false)
+		}
+
+		r.Freeze()
+
+		for i, d := range data {
+			got := r.Match(testTypeCommand, d, view.CIDEncNil, false)
+			if len(got) != 1 || len(want[i]) != 1 || got[0] != want[i][0] {
+				t.Fatalf("data %q, reversed %v: frozen Match returned %v, want %v", d, reversed, got, want[i])
+			}
+		}
+	}
+}
+
+func prefix(prefix string) func(r *Registrator) *Registrator {
+	return func(r *Registrator) *Registrator { return r.Prefix(testTypeCommand, prefix, nil) }
+}
+
+func glob(glob string) func(r *Registrator) *Registrator {
+	return func(r *Registrator) *Registrator { return r.Glob(testTypeCommand, glob, nil) }
+}
+
+func regex(expr string) func(r *Registrator) *Registrator {
+	return func(r *Registrator) *Registrator {
+		return r.Regex(testTypeCommand, regexp.MustCompile(expr), nil)
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/qioalice/devola/core/chat"
+	"github.com/qioalice/devola/core/ctx"
+)
+
+// Predicate is an additional condition of rule that the context
+// of occurred event must satisfy to the callback linked with that rule
+// be called.
+//
+// Use ChatType, SenderRole or MakePredicate to create it
+// and Registrator.Where to add it to the rule.
+type Predicate struct {
+
+	// The name of predicate (its string representation).
+	name string
+
+	// The unique number of predicate created by MakePredicate
+	// (functions can not be compared, so it identifies fn).
+	id uint64
+
+	// Exactly one of these fields is not empty.
+	chatTypes []chat.Type
+	roles     []chat.Role
+	fn        func(c *ctx.BaseCtx) bool
+}
+
+// ChatType returns a Predicate that is satisfied if the event is occurred
+// in the chat of one of passed types.
+func ChatType(types ...chat.Type) Predicate {
+	ss := make([]string, 0, len(types))
+	for _, typ := range types {
+		ss = append(ss, strconv.Itoa(int(typ)))
+	}
+	return Predicate{name: "ChatType(" + strings.Join(ss, ", ") + ")", chatTypes: types}
+}
+
+// SenderRole returns a Predicate that is satisfied if the event is sent
+// by the chat member with one of passed roles.
+func SenderRole(roles ...chat.Role) Predicate {
+	ss := make([]string, 0, len(roles))
+	for _, role := range roles {
+		ss = append(ss, strconv.Itoa(int(role)))
+	}
+	return Predicate{name: "SenderRole(" + strings.Join(ss, ", ") + ")", roles: roles}
+}
+
+// MakePredicate returns a Predicate that is satisfied if fn returns true.
+// name is used as predicate's string representation.
+//
+// fn is called for each occurred event the rule is matched by,
+// so it must be fast and must not change c.
+//
+// Each call returns a new predicate: rules with predicates made by different
+// calls are never considered as conflicting ones, even if name is the same.
+func MakePredicate(name string, fn func(c *ctx.BaseCtx) bool) Predicate {
+	return Predicate{name: name, id: atomic.AddUint64(&predicatesCounter, 1), fn: fn}
+}
+
+// predicatesCounter is the number of predicates created by MakePredicate.
+var predicatesCounter uint64
+
+// String returns a string representation of predicate.
+func (p Predicate) String() string {
+	return p.name
+}
+
+// MarshalText implements encoding.TextMarshaler interface.
+// Allows to represent predicate in JSON by its string representation.
+func (p Predicate) MarshalText() ([]byte, error) {
+	return []byte(p.name), nil
+}
+
+// isSatisfiedBy reports whether c satisfies p.
+func (p *Predicate) isSatisfiedBy(c *ctx.BaseCtx) bool {
+
+	switch {
+	case p.fn != nil:
+		return p.fn(c)
+
+	case p.chatTypes != nil:
+		typ := c.Chat.Type()
+		for _, t := range p.chatTypes {
+			if t == typ {
+				return true
+			}
+		}
+
+	case p.roles != nil:
+		for _, role := range p.roles {
+			if role == c.SenderRole {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// predicatesString returns a string representation of predicates.
+func predicatesString(predicates []Predicate) string {
+	ss := make([]string, 0, len(predicates))
+	for _, p := range predicates {
+		ss = append(ss, p.name)
+	}
+	return strings.Join(ss, ", ")
+}
+
+// key returns a canonical identity of p: predicates with equal keys
+// are satisfied by the same contexts. The order of types or roles
+// doesn't matter, so ChatType(1, 2) and ChatType(2, 1) have the same key.
+func (p *Predicate) key() string {
+
+	var (
+		kind   string
+		values []int
+	)
+
+	switch {
+	case p.fn != nil:
+		return "fn:" + strconv.FormatUint(p.id, 10)
+
+	case p.chatTypes != nil:
+		kind = "ChatType:"
+		for _, typ := range p.chatTypes {
+			values = append(values, int(typ))
+		}
+
+	case p.roles != nil:
+		kind = "SenderRole:"
+		for _, role := range p.roles {
+			values = append(values, int(role))
+		}
+
+	default:
+		return "none"
+	}
+
+	sort.Ints(values)
+
+	ss := make([]string, 0, len(values))
+	for i, v := range values {
+		if i == 0 || v != values[i-1] {
+			ss = append(ss, strconv.Itoa(v))
+		}
+	}
+
+	return kind + strings.Join(ss, ",")
+}
+
+// predicatesKey returns a canonical identity of all predicates of rule
+// (see Predicate.key). The order of predicates doesn't matter.
+func predicatesKey(predicates []Predicate) string {
+
+	keys := make([]string, 0, len(predicates))
+	for i := range predicates {
+		keys = append(keys, predicates[i].key())
+	}
+
+	sort.Strings(keys)
+
+	unique := keys[:0]
+	for i, key := range keys {
+		if i == 0 || key != keys[i-1] {
+			unique = append(unique, key)
+		}
+	}
+
+	return strings.Join(unique, ";")
+}
+
+// filter returns callbacks of cbs which predicates are satisfied by c
+// and which are not scoped to the handlers other than handlers
+// (see Group; handlers is nil when handlers themselves are filtered).
+// Callbacks with predicates are never returned if c is nil.
+//
+// Returns cbs itself (without allocation) if there is no callbacks
+// with predicates or scope.
+func filter(cbs []*Callback, c *ctx.BaseCtx, handlers []*Callback) []*Callback {
+
+	for i, cb := range cbs {
+		if cb.predicates == nil && cb.scope == nil {
+			continue
+		}
+
+		filtered := append(make([]*Callback, 0, len(cbs)), cbs[:i]...)
+		for _, cb := range cbs[i:] {
+			if cb.isAllowedFor(c) && cb.isScopedIn(handlers) {
+				filtered = append(filtered, cb)
+			}
+		}
+
+		return filtered
+	}
+
+	return cbs
+}
@@ -0,0 +1,106 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/qioalice/devola/core/chat"
+	"github.com/qioalice/devola/core/ctx"
+	"github.com/qioalice/devola/core/event"
+	"github.com/qioalice/devola/core/session"
+)
+
+// Chat types and roles used by tests.
+const (
+	testChatPrivate chat.Type = 1
+	testChatGroup   chat.Type = 2
+
+	testRoleMember chat.Role = 1
+	testRoleAdmin  chat.Role = 2
+)
+
+// Predicates must be checked against the chat and the sender role
+// backend reports by ChatOf passed to Dispatch.
+func TestDispatchPredicates(t *testing.T) {
+
+	r := makeTestRegistrator()
+	if err := r.RegenerateRequiredTypes(reflect.TypeOf((*testCtx)(nil))); err != nil {
+		t.Fatalf("RegenerateRequiredTypes: unexpected error: %v", err)
+	}
+
+	typed := For[testCtx](r)
+	register := func(name string, predicates ...Predicate) {
+		_, err := typed.Complex(testTypeCommand, "/start", nil).Where(predicates...).
+			Handler(func(c *testCtx) { c.calls = append(c.calls, name) })
+		if err != nil {
+			t.Fatalf("Handler %s: unexpected error: %v", name, err)
+		}
+	}
+
+	register("private", ChatType(testChatPrivate))
+	register("group admin", ChatType(testChatGroup), SenderRole(testRoleAdmin))
+	if _, err := typed.MainHandler(func(c *testCtx) { c.calls = append(c.calls, "main") }); err != nil {
+		t.Fatalf("MainHandler: unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		chatOf ChatOf
+		want   []string
+	}{
+		{"private", chatOf(testChatPrivate, testRoleMember), []string{"private"}},
+		{"group admin", chatOf(testChatGroup, testRoleAdmin), []string{"group admin"}},
+		{"group member", chatOf(testChatGroup, testRoleMember), []string{"main"}},
+		{"no chat", nil, []string{"main"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			var c testCtx
+			c.Event.Type, c.Event.Data = testTypeCommand, event.Data("/start")
+
+			if !r.Dispatch(unsafe.Pointer(&c), tt.chatOf) {
+				t.Fatalf("Dispatch: got false, want true")
+			}
+			if !reflect.DeepEqual(c.calls, tt.want) {
+				t.Fatalf("got calls %v, want %v", c.calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegenerateRequiredTypesIncompatible(t *testing.T) {
+
+	// The layout of context types made before Chat and SenderRole
+	// have been added to ctx.BaseCtx.
+	type oldCtx struct {
+		E     event.Event
+		S     session.Session
+		calls []string
+	}
+
+	r := makeTestRegistrator()
+	err := r.RegenerateRequiredTypes(reflect.TypeOf((*oldCtx)(nil)))
+	if err == nil || err.Code() != ECBadContext {
+		t.Fatalf("RegenerateRequiredTypes: got error %v, want ECBadContext", err)
+	}
+
+	// Required types must be left unchanged.
+	if err := r.MainHandler(func(*ctx.BaseCtx) {}); err != nil {
+		t.Fatalf("MainHandler: unexpected error: %v", err)
+	}
+}
+
+// chatOf returns ChatOf that reports the chat of typ and role.
+func chatOf(typ chat.Type, role chat.Role) ChatOf {
+	return func(unsafe.Pointer) (chat.IDT, chat.Role) {
+		return chat.NewIDT(42, typ), role
+	}
+}
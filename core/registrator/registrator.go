@@ -6,11 +6,18 @@
 package registrator
 
 import (
+	"fmt"
 	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"unsafe"
 
+	"github.com/qioalice/devola/core/chat"
+	"github.com/qioalice/devola/core/ctx"
+	"github.com/qioalice/devola/core/errors"
 	"github.com/qioalice/devola/core/event"
-	"github.com/qioalice/devola/core/sys/fn"
 	"github.com/qioalice/devola/core/view"
 )
 
@@ -22,60 +29,33 @@ import (
 // some next callback should be applied and then call Handler or Middleware method
 // to flush it.
 // You can also use MainHandler or MainMiddleware methods to set up general,
-// regular callbacks.
+// regular callbacks, and Fallback method to set up handlers that are called
+// when there is no handlers for occurred event in the current view.
 //
 // But! You must pass callback with compatible type with your context type!
 // If your context type has been changed, use RegenerateRequiredTypes method
 // to updated restriction rules.
+//
+// Accumulated rules are shared by all users of Registrator, so the chain of
+// Simple, Complex, pattern, Where, Alias, Describe methods and the Handler
+// or Middleware call that flushes it must be made by one goroutine only.
+// If you register callbacks from different goroutines, use a separate Group
+// (see Registrator.Group) in each of them: groups accumulate rules on their own.
+// Match, Routes, Unregister and all other methods can be called concurrently.
 type Registrator struct {
 
 	// Link to View ID converter (used in Registrator.save method).
 	converter *view.IDConv
 
-	// There is 3 important entity using which you can get or store any callback:
-	// event type (event.Type), event data (event.Data) and view ID encoded (view.IDEnc),
-	// where
-	// event type is the type of occurred event,
-	// event data is the body of occurred event,
-	// view ID encoded is the current View ID in the session associated with occurred event.
-	//
-	// By default you should determine all these 3 things for each callback
-	// at the registering operation and then use them to get registered callback.
-	//
-	// But it is possible that you don't use sessions (and you don't have View ID)
-	// for example, or it's meaningless to determine events by its data
-	// because it is empty or too complex and different (for example if it is
-	// text message and text message can be absolutely any), or you want to
-	// register or get the callback that will be called for all untyped events, etc.
-	//
-	// Because of that there is 5 sections of storage.
-
-	// [ 1 SECTION ]
-	// handlers, middlewares storage:
-	// occurred event type -> current View ID -> occurred event data.
-	handlersWhen    map[event.Type]map[view.IDEnc]map[event.Data][]unsafe.Pointer
-	middlewaresWhen map[event.Type]map[view.IDEnc]map[event.Data][]unsafe.Pointer
-
-	// [ 2 SECTION ]
-	// handlers, middlewares storage:
-	// occurred event type -> occurred event data.
-	handlersJust    map[event.Type]map[event.Data][]unsafe.Pointer
-	middlewaresJust map[event.Type]map[event.Data][]unsafe.Pointer
-
-	// [ 3 SECTION ]
-	// main handlers, middlewares storage.
-	handlersMain    []unsafe.Pointer // T is *[]FViewHandler
-	middlewaresMain []unsafe.Pointer // T is *[]FViewMiddleware
-
-	// [ 4 SECTION ]
-	// occurred event type -> current View ID.
-	handlerTextWhen     map[event.Type]map[view.IDEnc][]unsafe.Pointer
-	middlewaresTextWhen map[event.Type]map[view.IDEnc][]unsafe.Pointer
-
-	// [ 5 SECTION ]
-	// occurred event type.
-	handlerTextJust     map[event.Type][]unsafe.Pointer
-	middlewaresTextJust map[event.Type][]unsafe.Pointer
+	// Protects accumulatedRules and required types,
+	// serializes all operations that change the storage.
+	mu sync.Mutex
+
+	// The current storage of all registered callbacks.
+	// Always holds *storage object, that MUST NOT be changed after it
+	// has been stored (a new one is built and stored instead).
+	// So, Match never locks and can be called concurrently with registration.
+	storage atomic.Value
 
 	// Generated type the registered handlers must have.
 	handlerTypeRequired reflect.Type
@@ -83,9 +63,13 @@ type Registrator struct {
 	// Generated type the registered middlewares must have.
 	middlewareTypeRequired reflect.Type
 
+	// Generated type the registered around middlewares must have.
+	aroundTypeRequired reflect.Type
+
 	// The set of rules to which next generated callback (handler or middleware)
 	// will be applied.
 	// Is accumulated by Simple or Complex, is flushed by Handler or Middleware.
+	// Must be built by one goroutine at a time (see Registrator).
 	accumulatedRules []rule
 
 	// The function that determines what event type can be considered "simple"
@@ -93,6 +77,21 @@ type Registrator struct {
 	// "Simple" is the type of event that will be handled by callbacks from
 	// 4 or 5 sections - independent by event data.
 	simplesChecker func(typ event.Type) (isSimple bool)
+
+	// Reject registrations with duplicated or shadowed routes (see ParamStrict).
+	isStrict bool
+
+	// Reject all registrations and unregistrations (see Freeze).
+	isFrozen bool
+
+	// Event types which event data is compared case-insensitively
+	// (see ParamCaseFolding).
+	caseFolded [256]bool
+
+	// The max number of suggestions and the hook that is called by MatchCtx
+	// with them if there is no route for occurred event (see ParamSuggestions).
+	suggestionsMax int
+	onNotFound     func(c *ctx.BaseCtx, suggestions []event.Data)
 }
 
 // Text marks that the callback passed into the next Handler or Middleware
@@ -108,7 +107,105 @@ func (r *Registrator) Complex(typ event.Type, what string, when []string) *Regis
 	// *(*[]view.ID)(unsafe.Pointer(&when)) is
 	// []string -> []view.ID conversion without memory reallocation
 	e := makeRule(typ, event.Data(what), *(*[]view.ID)(unsafe.Pointer(&when)))
+	return r.accumulate(e)
+}
+
+// Prefix marks that the callback passed into the next Handler or Middleware
+// methods will be called when event with typ will be occurred and its data
+// will start with prefix.
+//
+// The rest of event data will be available as event.CCaptureRest capture.
+func (r *Registrator) Prefix(typ event.Type, prefix string, when []string) *Registrator {
+	return r.pattern(typ, PatternPrefix, prefix, nil, when)
+}
+
+// Glob marks that the callback passed into the next Handler or Middleware
+// methods will be called when event with typ will be occurred and its data
+// will match glob (see PatternGlob for supported syntax).
+//
+// All "{name}" parts of glob will be available as captures with the same names.
+func (r *Registrator) Glob(typ event.Type, glob string, when []string) *Registrator {
+	return r.pattern(typ, PatternGlob, glob, compileGlob(glob), when)
+}
+
+// Regex marks that the callback passed into the next Handler or Middleware
+// methods will be called when event with typ will be occurred and its data
+// will match expr entirely: expr is anchored at both ends, so "/buy"
+// matches only "/buy" (use "/buy.*" to match "/buy 2" too).
+//
+// All named groups of expr will be available as captures with the same names.
+func (r *Registrator) Regex(typ event.Type, expr *regexp.Regexp, when []string) *Registrator {
+	if expr == nil {
+		return r
+	}
+	return r.pattern(typ, PatternRegex, expr.String(), expr, when)
+}
+
+// pattern is the same as Complex but accumulates pattern rule.
+func (r *Registrator) pattern(typ event.Type, kind PatternKind, source string, re *regexp.Regexp, when []string) *Registrator {
+	e := makePatternRule(typ, kind, source, re, *(*[]view.ID)(unsafe.Pointer(&when)))
+	return r.accumulate(e)
+}
+
+// Where adds predicates to the last accumulated rule (by Simple, Complex
+// or pattern methods). Thus the callback passed into the next Handler or
+// Middleware methods will be called for the event that matches this rule
+// only if the context of event satisfies all predicates.
+// Does nothing if there is no accumulated rules.
+//
+// It allows to link the same event with different handlers depends on
+// chat type, sender role or any custom condition:
+//
+//	r.Complex(typ, "start", nil).Where(ChatType(private)).Handler(h1)
+//	r.Complex(typ, "start", nil).Where(ChatType(group, supergroup)).Handler(h2)
+//
+// Predicates are checked only by MatchCtx and Dispatch. Chat and SenderRole
+// of ctx.BaseCtx must be filled before MatchCtx (Dispatch does it).
+func (r *Registrator) Where(predicates ...Predicate) *Registrator {
+	r.mu.Lock()
+	addPredicates(r.accumulatedRules, predicates)
+	r.mu.Unlock()
+	return r
+}
+
+// Alias adds aliases to the last accumulated rule (by Simple, Complex
+// or Prefix methods). Thus the callback passed into the next Handler or
+// Middleware methods will be called for the events with data that is
+// the same as rule's data or any of aliases:
+//
+//	r.Complex(typ, "start", nil).Alias("begin", "go").Handler(h)
+//
+// Does nothing if there is no accumulated rules.
+// Aliases of glob and regex rules are ignored.
+func (r *Registrator) Alias(aliases ...string) *Registrator {
+	r.mu.Lock()
+	addAliases(r.accumulatedRules, aliases)
+	r.mu.Unlock()
+	return r
+}
+
+// addPredicates adds predicates to the last rule of rules (if any).
+func addPredicates(rules []rule, predicates []Predicate) {
+	if n := len(rules); n != 0 && len(predicates) != 0 {
+		last := &rules[n-1]
+		last.Predicates = append(last.Predicates[:len(last.Predicates):len(last.Predicates)], predicates...)
+	}
+}
+
+// addAliases adds aliases to the last rule of rules (if any).
+func addAliases(rules []rule, aliases []string) {
+	if n := len(rules); n != 0 && len(aliases) != 0 {
+		last := &rules[n-1]
+		last.Aliases = append(last.Aliases[:len(last.Aliases):len(last.Aliases)],
+			*(*[]event.Data)(unsafe.Pointer(&aliases))...)
+	}
+}
+
+// accumulate appends e to the accumulated rules.
+func (r *Registrator) accumulate(e *rule) *Registrator {
+	r.mu.Lock()
 	r.accumulatedRules = append(r.accumulatedRules, *e)
+	r.mu.Unlock()
 	return r
 }
 
@@ -122,11 +219,26 @@ func (r *Registrator) Complex(typ event.Type, what string, when []string) *Regis
 // or the same as your context extender returns, if you extends the context.
 // Otherwise a not nil EBadCallback error object is returned.
 //
+// Use RegisterHandler instead if you want to unregister the handler later
+// or if r is in strict mode or can be frozen: Handler panics if handler's routes
+// are conflicted with already registered ones (see ParamStrict)
+// or r is frozen (see Freeze), because EBadCallback can not report it.
+//
 // ATTENTION!
 // If any error will occur while trying to register handler,
 // list of all accumulated events will not be cleared!
 func (r *Registrator) Handler(handler interface{}) *EBadCallback {
-	return r.save(handler, false, r.handlerTypeRequired)
+	_, err := r.save(handler, kindHandler, false)
+	return badCallbackOf(err)
+}
+
+// RegisterHandler is the same as Handler but also returns a Handle
+// using which the handler can be unregistered later.
+// In strict mode a not nil EConflict error object is returned if handler's routes
+// are duplicated or shadowed by already registered ones.
+// An error with ECFrozen code is returned if r is frozen.
+func (r *Registrator) RegisterHandler(handler interface{}) (*Handle, errors.Error) {
+	return r.save(handler, kindHandler, false)
 }
 
 // Middleware links all accumulated events by Simple or Complex methods
@@ -139,11 +251,25 @@ func (r *Registrator) Handler(handler interface{}) *EBadCallback {
 // or the same as your context extender returns, if you extends the context.
 // Otherwise a not nil EBadCallback error object is returned.
 //
+// Use RegisterMiddleware instead if you want to unregister the middleware later
+// or if r is in strict mode or can be frozen (Middleware panics the same way
+// as Handler does).
+//
 // ATTENTION!
 // If any error will occur while trying to register middleware,
 // list of all accumulated events will not be cleared!
 func (r *Registrator) Middleware(middleware interface{}) *EBadCallback {
-	return r.save(middleware, true, r.middlewareTypeRequired)
+	_, err := r.save(middleware, kindMiddleware, false)
+	return badCallbackOf(err)
+}
+
+// RegisterMiddleware is the same as Middleware but also returns a Handle
+// using which the middleware can be unregistered later.
+// In strict mode a not nil EConflict error object is returned if middleware's
+// routes are shadowed by already registered ones.
+// An error with ECFrozen code is returned if r is frozen.
+func (r *Registrator) RegisterMiddleware(middleware interface{}) (*Handle, errors.Error) {
+	return r.save(middleware, kindMiddleware, false)
 }
 
 // MainHandler register handler as main handler (handles all events).
@@ -153,16 +279,17 @@ func (r *Registrator) Middleware(middleware interface{}) *EBadCallback {
 // handler type should be "func(*T)" where T is the same type as backend Ctx
 // or the same as your context extender returns, if you extends the context.
 // Otherwise a not nil EBadCallback error object is returned.
+// Panics if r is frozen (use RegisterMainHandler to get an error instead).
 func (r *Registrator) MainHandler(handler interface{}) *EBadCallback {
+	_, err := r.save(handler, kindHandler, true)
+	return badCallbackOf(err)
+}
 
-	// Handler will be registered as main handler only when accumulatedRules
-	// field is empty.
-
-	currentRules := r.accumulatedRules
-	r.accumulatedRules = nil
-	returned := r.Handler(handler)
-	r.accumulatedRules = currentRules
-	return returned
+// RegisterMainHandler is the same as MainHandler but also returns a Handle
+// using which the handler can be unregistered later.
+// An error with ECFrozen code is returned if r is frozen.
+func (r *Registrator) RegisterMainHandler(handler interface{}) (*Handle, errors.Error) {
+	return r.save(handler, kindHandler, true)
 }
 
 // MainMiddleware register middleware as main middleware (checks all events).
@@ -172,81 +299,691 @@ func (r *Registrator) MainHandler(handler interface{}) *EBadCallback {
 // middleware type should be "func(*T) bool" where T is the same type as backend Ctx
 // or the same as your context extender returns, if you extends the context.
 // Otherwise a not nil EBadCallback error object is returned.
+// Panics if r is frozen (use RegisterMainMiddleware to get an error instead).
 func (r *Registrator) MainMiddleware(middleware interface{}) *EBadCallback {
+	_, err := r.save(middleware, kindMiddleware, true)
+	return badCallbackOf(err)
+}
+
+// RegisterMainMiddleware is the same as MainMiddleware but also returns
+// a Handle using which the middleware can be unregistered later.
+// An error with ECFrozen code is returned if r is frozen.
+func (r *Registrator) RegisterMainMiddleware(middleware interface{}) (*Handle, errors.Error) {
+	return r.save(middleware, kindMiddleware, true)
+}
+
+// Around links all accumulated events by Simple or Complex methods
+// with passed around middleware (and then list of all accumulated events
+// will be cleared).
+//
+// Around middleware is a middleware that wraps the rest of the chain:
+// it takes a next function, that calls all next middlewares and handlers,
+// so it can do something before and after them (measure time, recover panics,
+// commit transactions, log results).
+// If around middleware doesn't call next, neither of next middlewares
+// nor handlers will be called (as if bool middleware returns false).
+//
+// Around middlewares are stored and called in the same ordered chain
+// with regular middlewares (see Serve).
+//
+// If there are no accumulated events, the middleware will be registered as
+// main around middleware (wraps all events).
+//
+// middleware type should be "func(*T, func())" where T is the same type
+// as backend Ctx or the same as your context extender returns,
+// if you extends the context.
+// Otherwise a not nil EBadCallback error object is returned.
+func (r *Registrator) Around(middleware interface{}) (*Handle, errors.Error) {
+	return r.save(middleware, kindAround, false)
+}
+
+// MainAround register around middleware as main middleware (wraps all events).
+//
+// Accumulated events are not used and are not cleared.
+func (r *Registrator) MainAround(middleware interface{}) (*Handle, errors.Error) {
+	return r.save(middleware, kindAround, true)
+}
+
+// Fallback registers handler as fallback handler of each View ID from when.
+//
+// Fallback handler is called when event occurred in session with one of
+// these View IDs has no handlers registered for it (neither for the View ID
+// nor without View ID). Thus each view can react to unexpected events
+// in its own context. If the view has no fallback handler,
+// main handlers are called instead (see Match).
+//
+// If when is empty, the handler will be registered as main handler.
+//
+// handler type should be "func(*T)" where T is the same type as backend Ctx
+// or the same as your context extender returns, if you extends the context.
+// Otherwise a not nil EBadCallback error object is returned.
+// In strict mode a not nil EConflict error object is returned if some view
+// already has fallback handler.
+//
+// Accumulated events are not used and are not cleared.
+func (r *Registrator) Fallback(handler interface{}, when []string) (*Handle, errors.Error) {
+	c, haveType := r.adapt(handler, kindHandler)
+	return r.saveFallback(c, haveType, *(*[]view.ID)(unsafe.Pointer(&when)))
+}
+
+// Back registers the built-in "back" handler for the event of type typ
+// with data in views from when (or in any view if when is empty).
+//
+// The handler returns the session to its previous view and renders it
+// (see ctx.BaseCtx.Back), so "Back" buttons don't need to be handled
+// by each view.
+//
+// Accumulated events are not used and are not cleared.
+func (r *Registrator) Back(nav *ctx.Navigator, typ event.Type, data string, when []string) (*Handle, errors.Error) {
+
+	cb := &Callback{
+		handler: func(c unsafe.Pointer) { _ = (*ctx.BaseCtx)(c).Back(nav) },
+		name:    "registrator.Back",
+	}
+
+	rules := []rule{*makeRule(typ, event.Data(data), *(*[]view.ID)(unsafe.Pointer(&when)))}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-	// Middleware will be registered as main middleware only when accumulatedRules
-	// field is empty.
+	if err := r.commit(r.makeRegistration(cb, false, rules)); err != nil {
+		return nil, err
+	}
 
-	currentRules := r.accumulatedRules
-	r.accumulatedRules = nil
-	returned := r.Middleware(middleware)
-	r.accumulatedRules = currentRules
-	return returned
+	return &Handle{r: r, cb: cb}, nil
 }
 
-// RegenerateRequiredTypes updates handlerTypeRequired and middlewareTypeRequired
-// fields by new generated types that will be depended from ctxType.
+// Unregister removes callback h is associated with from all rules
+// it has been linked with. Returns false if h is nil or already unregistered.
+// Returns false and an error with ECFrozen code if r is frozen.
+//
+// It's the same as h.Unregister().
+func (r *Registrator) Unregister(h *Handle) (bool, errors.Error) {
+
+	if h == nil || h.r != r {
+		return false, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.isFrozen {
+		return false, errors.MakeError(ECFrozen, "Registrator is frozen, no callbacks can be unregistered.")
+	}
+
+	if h.cb.isUnregistered {
+		return false, nil
+	}
+
+	// Group middleware may have no registrations yet,
+	// but it must not be applied to the group's next routes anyway.
+	h.cb.isUnregistered = true
+
+	current := r.current()
+	registrations := make([]*registration, 0, len(current.registrations))
+
+	for _, reg := range current.registrations {
+		if reg.cb != h.cb && reg.scope != h.cb {
+			registrations = append(registrations, reg)
+		}
+	}
+
+	if len(registrations) != len(current.registrations) {
+		r.storage.Store(r.makeStorage(registrations))
+	}
+
+	return true, nil
+}
+
+// RegenerateRequiredTypes updates handlerTypeRequired, middlewareTypeRequired
+// and aroundTypeRequired fields by new generated types that will be depended
+// from ctxType.
 // It allows to register handler or middlewares with a new signature
 // after context has been extended.
-func (r *Registrator) RegenerateRequiredTypes(ctxType reflect.Type) {
-
-	// It excepts ctxType is not nil
+//
+// Returns an error with ECBadContext code and changes nothing if ctxType
+// can not be casted to *ctx.BaseCtx (see ctx.IsCompatible),
+// because matched callbacks are called with such casted pointers.
+func (r *Registrator) RegenerateRequiredTypes(ctxType reflect.Type) errors.Error {
+
+	if !ctx.IsCompatible(ctxType) {
+		return errors.MakeError(ECBadContext,
+			fmt.Sprintf("Context type %v has layout incompatible with ctx.BaseCtx.", ctxType))
+	}
 
 	inBoth := []reflect.Type{ctxType}
 	outMiddleware := []reflect.Type{reflect.TypeOf(true)}
 
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	r.handlerTypeRequired = reflect.FuncOf(inBoth, nil, false)
 	r.middlewareTypeRequired = reflect.FuncOf(inBoth, outMiddleware, false)
+	r.aroundTypeRequired = reflect.FuncOf(append(inBoth, reflect.TypeOf(func() {})), nil, false)
+	return nil
 }
 
 // Match returns a slice of handlers or slice of middlewares (isMiddleware flag)
-// associated with an event, identification signs of which are passed.
-// A real type of return value will be *[]unsafe.Pointer.
-func (r *Registrator) Match(typ event.Type, data event.Data, viewID view.IDEnc, isMiddleware bool) []unsafe.Pointer {
-	return r.access(nil, typ, data, viewID, isMiddleware)
+// that must be called for an event, identification signs of which are passed.
+//
+// Handlers are resolved in the following order (first found level wins):
+// 1. Handlers registered for event and viewID,
+// 2. Handlers registered for event without View ID,
+// 3. Fallback handlers of viewID (see Fallback),
+// 4. Main handlers.
+// Pattern rules of each of 1 and 2 levels are checked only if there is
+// no exactly matched handlers at the same level.
+//
+// Middlewares are main middlewares and then middlewares of the same event
+// and level handlers have been found at (only 1 and 2 levels have them).
+//
+// Callbacks linked with rules that have predicates (see Where) are skipped,
+// because there is no context to check them.
+// Use MatchCtx if you need to get captured parts of event data
+// or to check predicates.
+func (r *Registrator) Match(typ event.Type, data event.Data, viewID view.IDEnc, isMiddleware bool) []*Callback {
+	middlewares, handlers, _, _ := r.resolve(typ, data, viewID, nil)
+	if isMiddleware {
+		return middlewares
+	}
+	return handlers
+}
+
+// MatchCtx is the same as Match but takes event type, event data and
+// View ID encoded from c.
+// Callbacks linked with rules that have predicates are matched only if c
+// satisfies all of them (otherwise it is considered as there is no such rules).
+// If callbacks are matched by pattern rule, the captured parts of event data
+// are saved to the c.Event.Captures.
+//
+// If there is no route for occurred event (handlers are fallback or main ones)
+// and the suggestions hook is set (see ParamSuggestions),
+// it is called with c and the closest registered event data
+// before handlers are returned (only if isMiddleware is false).
+func (r *Registrator) MatchCtx(c *ctx.BaseCtx, isMiddleware bool) []*Callback {
+
+	if c == nil {
+		return nil
+	}
+
+	middlewares, handlers := r.matchCtx(c, !isMiddleware)
+	if isMiddleware {
+		return middlewares
+	}
+	return handlers
+}
+
+// matchCtx is the MatchCtx and Dispatch's core.
+// Returns middlewares and handlers for c and saves captures to the c.Event.
+// Calls the suggestions hook if there is no route for c and notify is true.
+func (r *Registrator) matchCtx(c *ctx.BaseCtx, notify bool) (middlewares, handlers []*Callback) {
+
+	middlewares, handlers, captures, isRouted := r.resolve(c.Event.Type, c.Event.Data, c.Session.ViewIDEncoded, c)
+	if captures != nil {
+		c.Event.Captures = captures
+	}
+
+	if notify && !isRouted && r.onNotFound != nil &&
+		c.Event.Type != event.CTypeInvalid && !r.simplesChecker(c.Event.Type) {
+		r.onNotFound(c, r.SuggestCtx(c, r.suggestionsMax))
+	}
+
+	return middlewares, handlers
+}
+
+// ChatOf returns the chat the event of backend context object c is occurred in
+// and the role of its sender (chat.CRoleUnknown if it can not be determined).
+// Backends provide it (see bridge.Bridge's CtxChat) to Dispatch.
+type ChatOf func(c unsafe.Pointer) (idt chat.IDT, role chat.Role)
+
+// Dispatch is the way backends pass occurred events to r.
+//
+// It fills Chat and SenderRole fields of ctx.BaseCtx of backend context object c
+// using chatOf, so predicates (see Where) can be checked,
+// then matches middlewares and handlers (see MatchCtx) and calls them
+// (see Serve). Returns true if handlers has been called.
+//
+// c must be a pointer to the object of context type r is created for.
+// If chatOf is nil, Chat and SenderRole fields are left as is.
+func (r *Registrator) Dispatch(c unsafe.Pointer, chatOf ChatOf) (isHandled bool) {
+
+	base := (*ctx.BaseCtx)(c)
+	if base == nil {
+		return false
+	}
+
+	if chatOf != nil {
+		base.Chat, base.SenderRole = chatOf(c)
+	}
+
+	middlewares, handlers := r.matchCtx(base, true)
+	return Serve(c, middlewares, handlers)
+}
+
+// resolve is the Match and MatchCtx's core.
+// Returns middlewares and handlers that must be called for an event
+// (see Match for resolution order) and captures if handlers
+// has been matched by pattern rule, and whether handlers are found by route
+// (not fallback or main ones).
+// Predicates are checked using c (callbacks with predicates are skipped if c is nil).
+func (r *Registrator) resolve(typ event.Type, data event.Data, viewID view.IDEnc, c *ctx.BaseCtx) (middlewares, handlers []*Callback, captures event.Captures, isRouted bool) {
+
+	s := r.current()
+
+	// Sessions and buttons can keep encoded View IDs of renamed views.
+	viewID = r.converter.Canonical(viewID)
+
+	if typ != event.CTypeInvalid {
+		for _, levelViewID := range [2]view.IDEnc{viewID, view.CIDEncNil} {
+			if handlers, captures = r.match(s, c, nil, typ, data, levelViewID, false); len(handlers) != 0 {
+				middlewares, _ = r.match(s, c, handlers, typ, data, levelViewID, true)
+				break
+			}
+			if viewID == view.CIDEncNil {
+				break
+			}
+		}
+	}
+
+	isRouted = len(handlers) != 0
+
+	if !isRouted && viewID != view.CIDEncNil {
+		handlers = s.handlersFallback[viewID]
+	}
+
+	if len(handlers) == 0 {
+		handlers = r.access(s, nil, event.CTypeInvalid, event.CDataNil, view.CIDEncNil, false)
+	}
+
+	mainMiddlewares := r.access(s, nil, event.CTypeInvalid, event.CDataNil, view.CIDEncNil, true)
+	switch {
+	case len(mainMiddlewares) == 0:
+	case len(middlewares) == 0:
+		middlewares = mainMiddlewares
+	default:
+		middlewares = append(mainMiddlewares[:len(mainMiddlewares):len(mainMiddlewares)], middlewares...)
+	}
+
+	return middlewares, handlers, captures, isRouted
 }
 
-// save performs linking all accumulated events with passed
-// handler or middleware and returns nil if it was successfully.
+// match returns callbacks registered for event and exactly viewID
+// (without any fallbacks) which are allowed by c and handlers (see filter),
+// and captures if they has been matched by pattern rule.
+// handlers are already matched handlers if middlewares are matched (nil otherwise).
+func (r *Registrator) match(s *storage, c *ctx.BaseCtx, handlers []*Callback, typ event.Type, data event.Data, viewID view.IDEnc, isMiddleware bool) ([]*Callback, event.Captures) {
+
+	// Callbacks of "simple" types match any event data,
+	// so patterns must be checked before them. Otherwise exact matches first.
+	isSimpleType := r.simplesChecker(typ)
+
+	if !isSimpleType {
+		if cbs := filter(r.access(s, nil, typ, r.foldCase(typ, data), viewID, isMiddleware), c, handlers); len(cbs) != 0 {
+			return cbs, nil
+		}
+	}
+
+	if cbs, captures := s.matchPattern(c, handlers, typ, data, viewID, isMiddleware); len(cbs) != 0 {
+		return cbs, captures
+	}
+
+	if isSimpleType {
+		return filter(r.access(s, nil, typ, data, viewID, isMiddleware), c, handlers), nil
+	}
+
+	return nil, nil
+}
+
+// Freeze finishes the registration: all registrations and unregistrations
+// after Freeze are rejected (with an error with ECFrozen code),
+// so the set of routes can not be changed by mistake after the start.
+// There is no-op if r is already frozen.
+//
+// Because routes are not changed anymore, Freeze also builds prefix trees
+// of pattern rules, so Match checks only patterns which literal prefix
+// (prefix itself, the part of glob before the first wildcard) is a prefix
+// of event data instead of all patterns of event type.
+func (r *Registrator) Freeze() {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.isFrozen {
+		return
+	}
+
+	r.isFrozen = true
+
+	frozen := *r.current()
+	frozen.patternIndexes = frozen.indexPatterns()
+	r.storage.Store(&frozen)
+}
+
+// IsFrozen reports whether r is frozen (see Freeze).
+func (r *Registrator) IsFrozen() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.isFrozen
+}
+
+// save is an adapter of untyped API to the saveCallback.
 //
 // cb is handler or middleware functor,
-// isMiddleware reports whether cb is handler or middleware,
-// wantType is one of r.handlerTypeRequired or r.middlewareTypeRequired.
-func (r *Registrator) save(cb interface{}, isMiddleware bool, wantType reflect.Type) *EBadCallback {
+// kind reports whether cb is handler, middleware or around middleware.
+func (r *Registrator) save(cb interface{}, kind callbackKind, isMain bool) (*Handle, errors.Error) {
+	c, haveType := r.adapt(cb, kind)
+	return r.saveCallback(c, haveType, kind, isMain)
+}
+
+// badCallbackOf returns err as EBadCallback for the methods,
+// that have returned only it before strict mode and Freeze were introduced.
+// Panics if err is not nil and is not EBadCallback (conflict or frozen error),
+// because returning nil would report that registration has been successful.
+func badCallbackOf(err errors.Error) *EBadCallback {
+	if err == nil {
+		return nil
+	}
+	if e, ok := err.(*EBadCallback); ok {
+		return e
+	}
+	panic(err)
+}
+
+// adapt converts handler or middleware functor cb to the Callback object
+// and returns it and cb's type.
+// Returned Callback is nil if cb is nil or has incompatible type.
+func (r *Registrator) adapt(cb interface{}, kind callbackKind) (*Callback, reflect.Type) {
+
+	haveType := reflect.TypeOf(cb)
+
+	r.mu.Lock()
+	wantType := r.requiredType(kind)
+	r.mu.Unlock()
+
+	if v := reflect.ValueOf(cb); v.Kind() != reflect.Func || v.IsNil() || haveType != wantType {
+		return nil, haveType
+	}
+
+	return makeCallback(cb, kind), haveType
+}
+
+// requiredType returns the type registering callbacks of kind must have.
+// r.mu must be locked.
+func (r *Registrator) requiredType(kind callbackKind) reflect.Type {
+	switch kind {
+	case kindMiddleware:
+		return r.middlewareTypeRequired
+	case kindAround:
+		return r.aroundTypeRequired
+	}
+	return r.handlerTypeRequired
+}
+
+// saveCallback performs linking all accumulated events (or no events if isMain)
+// with passed handler or middleware and returns a Handle of registration
+// if it was successfully.
+//
+// cb is handler or middleware (nil if haveType is incompatible),
+// haveType is the type of function cb has been made of,
+// kind reports whether cb is handler, middleware or around middleware.
+func (r *Registrator) saveCallback(cb *Callback, haveType reflect.Type, kind callbackKind, isMain bool) (*Handle, errors.Error) {
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var rules []rule
+	if !isMain && len(r.accumulatedRules) != 0 {
+		rules = r.accumulatedRules
+	}
+
+	h, err := r.register(cb, haveType, kind, rules)
+	if err == nil && rules != nil {
+		r.accumulatedRules = nil
+	}
+
+	return h, err
+}
+
+// register links cb with rules (or registers it as main callback
+// if rules is nil) and returns a Handle of registration if it was successfully.
+// Arguments are the same as saveCallback's ones.
+// r.mu must be locked.
+func (r *Registrator) register(cb *Callback, haveType reflect.Type, kind callbackKind, rules []rule) (*Handle, errors.Error) {
+
+	if err := r.checkType(cb, haveType, kind, rules); err != nil {
+		return nil, err
+	}
+
+	if err := r.commit(r.makeRegistration(cb, kind.isMiddleware(), rules)); err != nil {
+		return nil, err
+	}
+
+	return &Handle{r: r, cb: cb}, nil
+}
+
+// saveFallback performs registering of cb as fallback handler
+// of each View ID from when (or as main handler if when is empty)
+// and returns a Handle of registration if it was successfully.
+func (r *Registrator) saveFallback(cb *Callback, haveType reflect.Type, when []view.ID) (*Handle, errors.Error) {
+
+	if len(when) == 0 {
+		return r.saveCallback(cb, haveType, kindHandler, true)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.registerFallback(cb, haveType, when)
+}
 
-	// cb == nil also handlers here
-	if haveType := reflect.TypeOf(cb); haveType != wantType {
-		return makeEBadCallback(isMiddleware, haveType == nil, wantType.String(), haveType.String(), r.accumulatedRules)
+// registerFallback is the saveFallback's core. when must not be empty.
+// r.mu must be locked.
+func (r *Registrator) registerFallback(cb *Callback, haveType reflect.Type, when []view.ID) (*Handle, errors.Error) {
+
+	rules := []rule{*makeRule(event.CTypeInvalid, event.CDataNil, when)}
+
+	if err := r.checkType(cb, haveType, kindHandler, rules); err != nil {
+		return nil, err
 	}
 
-	// FnPtrCallable may return nil only if cb == nil, there is no need a nil check
-	cbPtr := fn.TakeCallableAddr(cb)
+	reg := r.makeRegistration(cb, false, rules)
+	reg.isFallback = true
 
-	// Reg as general handler/middleware
-	if r.accumulatedRules == nil {
-		r.access(cbPtr, event.CTypeInvalid, event.CDataNil, view.CIDEncNil, isMiddleware)
+	if err := r.commit(reg); err != nil {
+		return nil, err
+	}
+
+	return &Handle{r: r, cb: cb}, nil
+}
+
+// checkType returns a not nil EBadCallback if cb is nil
+// or haveType is not the type registering callbacks must have.
+// r.mu must be locked.
+func (r *Registrator) checkType(cb *Callback, haveType reflect.Type, kind callbackKind, rules []rule) *EBadCallback {
+
+	wantType := r.requiredType(kind)
+
+	// nil callbacks (typed and untyped) also handled here
+	if cb != nil && haveType == wantType {
 		return nil
 	}
 
-	for _, rule := range r.accumulatedRules {
-		if len(rule.When) == 0 {
-			r.access(cbPtr, rule.Type, rule.Data, view.CIDEncNil, isMiddleware)
-			continue
+	isNil := haveType == nil || cb == nil && haveType == wantType
+	return makeEBadCallback(kind.isMiddleware(), isNil, typeString(wantType), typeString(haveType), rules)
+}
+
+// typeString returns a string representation of typ
+// or an empty string if typ is nil.
+func typeString(typ reflect.Type) string {
+	if typ == nil {
+		return ""
+	}
+	return typ.String()
+}
+
+// makeRegistration creates a new registration object of cb
+// with the copy of rules and calculates its targets.
+// rules must be nil if cb is main handler or main middleware.
+func (r *Registrator) makeRegistration(cb *Callback, isMiddleware bool, rules []rule) *registration {
+
+	reg := &registration{
+		cb:           cb,
+		isMiddleware: isMiddleware,
+	}
+
+	if rules == nil {
+		return reg
+	}
+
+	reg.rules = append(rules[:0:0], rules...)
+	for i := range reg.rules {
+		rule := &reg.rules[i]
+
+		if rule.Pattern == PatternGlob && r.isCaseFolded(rule.Type) {
+			rule.re = regexp.MustCompile(`(?i)` + rule.re.String())
+		}
+
+		c := cb
+		if len(rule.Predicates) != 0 {
+			c = cb.guard(rule.Predicates)
+		}
+
+		data := []event.Data{rule.Data}
+		if rule.Pattern == PatternExact || rule.Pattern == PatternPrefix {
+			data = append(data, rule.Aliases...)
 		}
-		for _, when := range rule.When {
-			viewID := r.converter.Encode(when)
-			r.access(cbPtr, rule.Type, rule.Data, viewID, isMiddleware)
+
+		viewIDs := []view.IDEnc{view.CIDEncNil}
+		if len(rule.When) != 0 {
+			viewIDs = viewIDs[:0]
+			// Views of rules are declared regardless of view.ParamAutoRegister.
+			_ = r.converter.Register(rule.When...)
+			for _, when := range rule.When {
+				viewIDs = append(viewIDs, r.converter.Encode(when))
+			}
+		}
+
+		for _, viewID := range viewIDs {
+			for _, d := range data {
+				reg.targets = append(reg.targets, target{rule, d, viewID, c})
+			}
+		}
+	}
+
+	return reg
+}
+
+// makeScopedRegistration creates a new registration object of group middleware
+// mw for the rules of handler (see Group).
+// The scoped copy of mw is saved to the storage, so mw is matched only
+// together with handler.
+func (r *Registrator) makeScopedRegistration(mw, handler *Callback, rules []rule) *registration {
+	reg := r.makeRegistration(mw.scopeTo(handler), true, rules)
+	reg.cb, reg.scope = mw, handler
+	return reg
+}
+
+// commit appends regs to the current registrations and publishes
+// a new storage built from them.
+// In strict mode nothing is committed if any of regs has conflicts.
+// Nothing is committed if r is frozen.
+// r.mu must be locked.
+func (r *Registrator) commit(regs ...*registration) errors.Error {
+
+	if r.isFrozen {
+		return errors.MakeError(ECFrozen, "Registrator is frozen, no callbacks can be registered.")
+	}
+
+	current := r.current().registrations
+	registrations := current[:len(current):len(current)]
+
+	for _, reg := range regs {
+		if r.isStrict {
+			if conflicts := r.conflictsOf(reg, registrations); len(conflicts) != 0 {
+				return makeEConflict(conflicts)
+			}
 		}
+		registrations = append(registrations, reg)
 	}
 
+	r.storage.Store(r.makeStorage(registrations))
 	return nil
 }
 
+// current returns the current storage snapshot.
+func (r *Registrator) current() *storage {
+	return r.storage.Load().(*storage)
+}
+
+// saveRule saves cb to the storage section rule and viewID are pointing to.
+// data is the rule's event data or one of its aliases.
+func (r *Registrator) saveRule(s *storage, cb *Callback, rule *rule, data event.Data, viewID view.IDEnc, isMiddleware bool) {
+	if rule.Pattern == PatternExact {
+		r.access(s, cb, rule.Type, r.foldCase(rule.Type, data), viewID, isMiddleware)
+	} else {
+		r.savePattern(s, cb, rule, data, viewID, isMiddleware)
+	}
+}
+
+// isCaseFolded reports whether event data of typ is compared
+// case-insensitively (see ParamCaseFolding).
+func (r *Registrator) isCaseFolded(typ event.Type) bool {
+	return r.caseFolded[typ]
+}
+
+// foldCase returns data in lower case if event data of typ is compared
+// case-insensitively, or data itself otherwise.
+func (r *Registrator) foldCase(typ event.Type, data event.Data) event.Data {
+	if !r.caseFolded[typ] {
+		return data
+	}
+	return event.Data(strings.ToLower(string(data)))
+}
+
+// savePattern saves cb to the 6th storage section (pattern callbacks).
+// rule must be a pattern rule cb is linked with,
+// data is the rule's event data or one of its aliases.
+//
+// If the slot with the same pattern and viewID already exists, cb is appended
+// to it, otherwise a new slot is created and appended to the end
+// (it's why patterns are checked in the order they has been registered).
+func (r *Registrator) savePattern(s *storage, cb *Callback, rule *rule, data event.Data, viewID view.IDEnc, isMiddleware bool) {
+
+	typ, data := rule.Type, r.foldCase(rule.Type, data)
+
+	storage := &s.handlersPattern
+	if isMiddleware {
+		storage = &s.middlewaresPattern
+	}
+
+	if *storage == nil {
+		*storage = make(map[event.Type][]*patternSlot)
+	}
+
+	for _, slot := range (*storage)[typ] {
+		if slot.viewID == viewID && slot.kind == rule.Pattern && slot.source == data {
+			slot.cbs = append(slot.cbs, cb)
+			return
+		}
+	}
+
+	slot := &patternSlot{
+		kind:   rule.Pattern,
+		source: data,
+		re:     rule.re,
+		fold:   r.isCaseFolded(typ),
+		viewID: viewID,
+		cbs:    []*Callback{cb},
+	}
+
+	(*storage)[typ] = append((*storage)[typ], slot)
+}
+
 // access does the one of two things Registrator.save and Registrator.Match describes.
 // It depends on whether cb is nil or not. Returns nil if works in "save" mode
 // or if requested callbacks not found in "Match" mode.
 // Detailed description inside.
-func (r *Registrator) access(cb unsafe.Pointer, typ event.Type, data event.Data, viewID view.IDEnc, isMiddleware bool) []unsafe.Pointer {
+func (r *Registrator) access(s *storage, cb *Callback, typ event.Type, data event.Data, viewID view.IDEnc, isMiddleware bool) []*Callback {
 
 	// Typedefs described below are created for a more compact way
 	// to describe read/write operations with Registrator's storages.
@@ -255,17 +992,17 @@ func (r *Registrator) access(cb unsafe.Pointer, typ event.Type, data event.Data,
 	// Thus, S1L3 - section 1, level 3 - an alias to the map type of the last
 	// "view" in 1st storage section callbacks.
 
-	type tS1L3 map[event.Data][]unsafe.Pointer
+	type tS1L3 map[event.Data][]*Callback
 	type tS1L2 map[view.IDEnc]tS1L3
 	type tS1L1 map[event.Type]tS1L2
 
-	type tS2L2 map[event.Data][]unsafe.Pointer
+	type tS2L2 map[event.Data][]*Callback
 	type tS2L1 map[event.Type]tS2L2
 
-	type tS4L2 map[view.IDEnc][]unsafe.Pointer
+	type tS4L2 map[view.IDEnc][]*Callback
 	type tS4L1 map[event.Type]tS4L2
 
-	type tS5L1 map[event.Type][]unsafe.Pointer
+	type tS5L1 map[event.Type][]*Callback
 
 	// ptrField is a pointer to some Registrator field.
 	// In all switch cases presented below the first action is initializing
@@ -292,7 +1029,7 @@ func (r *Registrator) access(cb unsafe.Pointer, typ event.Type, data event.Data,
 
 	// NOTE.
 	// All switch cases are numbered and have the same number as the section number
-	// in the Registator's fields description.
+	// in the storage's fields description.
 
 	switch {
 
@@ -301,18 +1038,18 @@ func (r *Registrator) access(cb unsafe.Pointer, typ event.Type, data event.Data,
 
 		switch {
 		case !isMiddleware:
-			ptrField = unsafe.Pointer(&r.handlersMain)
+			ptrField = unsafe.Pointer(&s.handlersMain)
 
 		case isMiddleware:
-			ptrField = unsafe.Pointer(&r.middlewaresMain)
+			ptrField = unsafe.Pointer(&s.middlewaresMain)
 		}
 
 		if isReg {
-			storage := *(*[]unsafe.Pointer)(ptrField)
+			storage := *(*[]*Callback)(ptrField)
 			storage = append(storage, cb)
-			*(*[]unsafe.Pointer)(ptrField) = storage
+			*(*[]*Callback)(ptrField) = storage
 		} else {
-			return *(*[]unsafe.Pointer)(ptrField)
+			return *(*[]*Callback)(ptrField)
 		}
 
 	// 5 SECTION: "TEXT CALLBACKS W/O VIEW ID".
@@ -320,10 +1057,10 @@ func (r *Registrator) access(cb unsafe.Pointer, typ event.Type, data event.Data,
 
 		switch {
 		case !isMiddleware:
-			ptrField = unsafe.Pointer(&r.handlerTextJust)
+			ptrField = unsafe.Pointer(&s.handlerTextJust)
 
 		case isMiddleware:
-			ptrField = unsafe.Pointer(&r.middlewaresTextJust)
+			ptrField = unsafe.Pointer(&s.middlewaresTextJust)
 		}
 
 		if isReg {
@@ -343,10 +1080,10 @@ func (r *Registrator) access(cb unsafe.Pointer, typ event.Type, data event.Data,
 
 		switch {
 		case !isMiddleware:
-			ptrField = unsafe.Pointer(&r.handlerTextWhen)
+			ptrField = unsafe.Pointer(&s.handlerTextWhen)
 
 		case isMiddleware:
-			ptrField = unsafe.Pointer(&r.middlewaresTextWhen)
+			ptrField = unsafe.Pointer(&s.middlewaresTextWhen)
 		}
 
 		if isReg {
@@ -370,10 +1107,10 @@ func (r *Registrator) access(cb unsafe.Pointer, typ event.Type, data event.Data,
 
 		switch {
 		case !isMiddleware:
-			ptrField = unsafe.Pointer(&r.handlersJust)
+			ptrField = unsafe.Pointer(&s.handlersJust)
 
 		case isMiddleware:
-			ptrField = unsafe.Pointer(&r.middlewaresJust)
+			ptrField = unsafe.Pointer(&s.middlewaresJust)
 		}
 
 		if isReg {
@@ -398,10 +1135,10 @@ func (r *Registrator) access(cb unsafe.Pointer, typ event.Type, data event.Data,
 
 		switch {
 		case !isMiddleware:
-			ptrField = unsafe.Pointer(&r.handlersWhen)
+			ptrField = unsafe.Pointer(&s.handlersWhen)
 
 		case isMiddleware:
-			ptrField = unsafe.Pointer(&r.middlewaresWhen)
+			ptrField = unsafe.Pointer(&s.middlewaresWhen)
 		}
 
 		if isReg {
@@ -432,13 +1169,28 @@ func (r *Registrator) access(cb unsafe.Pointer, typ event.Type, data event.Data,
 // view ID converter object and simples event type's checker.
 // It also sets that registered handlers or middlewares should be compatible
 // with passed context type.
-func MakeRegistrator(converter *view.IDConv, ctxType reflect.Type, simplesChecker func(typ event.Type) (isSimple bool)) *Registrator {
+//
+// If ctxType has layout incompatible with ctx.BaseCtx, no required types
+// are generated and each registration returns a not nil EBadCallback error
+// object with empty WantType. Call RegenerateRequiredTypes to get the reason.
+//
+// params might be only values returned by Param* functions of this package
+// (like ParamStrict), others are ignored.
+func MakeRegistrator(converter *view.IDConv, ctxType reflect.Type, simplesChecker func(typ event.Type) (isSimple bool), params ...interface{}) *Registrator {
 
 	var r Registrator
 
 	r.converter = converter
 	r.simplesChecker = simplesChecker
-	r.RegenerateRequiredTypes(ctxType)
+
+	for _, p := range params {
+		if p, ok := p.(param); ok && p != nil {
+			p(&r)
+		}
+	}
+
+	r.storage.Store(r.makeStorage(nil))
+	_ = r.RegenerateRequiredTypes(ctxType)
 
 	return &r
 }
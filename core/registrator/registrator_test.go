@@ -0,0 +1,169 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/qioalice/devola/core/ctx"
+	"github.com/qioalice/devola/core/event"
+	"github.com/qioalice/devola/core/view"
+)
+
+// Event types used by tests.
+const (
+	testTypeCommand event.Type = 1
+	testTypeButton  event.Type = 2
+	testTypeText    event.Type = 3
+)
+
+// makeTestRegistrator returns a new Registrator for *ctx.BaseCtx context
+// with testTypeText as the only "simple" event type.
+func makeTestRegistrator(params ...interface{}) *Registrator {
+	isSimple := func(typ event.Type) bool { return typ == testTypeText }
+	return MakeRegistrator(view.MakeIDConv(), reflect.TypeOf((*ctx.BaseCtx)(nil)), isSimple, params...)
+}
+
+// makeTestCtx returns a new context of event of type typ with data.
+func makeTestCtx(typ event.Type, data string) *ctx.BaseCtx {
+	c := new(ctx.BaseCtx)
+	c.Event.Type, c.Event.Data = typ, event.Data(data)
+	return c
+}
+
+func TestConcurrentRegistration(t *testing.T) {
+
+	const (
+		goroutines = 4
+		routes     = 200
+	)
+
+	r := makeTestRegistrator()
+	handler := func(*ctx.BaseCtx) {}
+
+	var (
+		wg      sync.WaitGroup
+		done    = make(chan struct{})
+		handles = make(chan *Handle, goroutines*routes)
+	)
+
+	// Matchers work until all registrations are done.
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					r.MatchCtx(makeTestCtx(testTypeCommand, fmt.Sprintf("/cmd%d_0", i)), false)
+					r.Routes()
+				}
+			}
+		}(i)
+	}
+
+	var registrators sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		registrators.Add(1)
+		go func(i int) {
+			defer registrators.Done()
+			g := r.Group()
+			for j := 0; j < routes; j++ {
+				h, err := g.Complex(testTypeCommand, fmt.Sprintf("/cmd%d_%d", i, j), nil).Handler(handler)
+				if err != nil {
+					t.Errorf("Handler: unexpected error: %v", err)
+					return
+				}
+				handles <- h
+			}
+		}(i)
+	}
+
+	// Unregister a half of registered handlers concurrently with registration.
+	var unregistered int
+	for n := 0; n < goroutines*routes/2; n++ {
+		ok, err := (<-handles).Unregister()
+		if err != nil || !ok {
+			t.Fatalf("Unregister: got %v, %v, want true, nil", ok, err)
+		}
+		unregistered++
+	}
+
+	registrators.Wait()
+	close(done)
+	wg.Wait()
+
+	if got, want := len(r.Routes()), goroutines*routes-unregistered; got != want {
+		t.Fatalf("got %d routes, want %d", got, want)
+	}
+
+}
+
+func TestUnregister(t *testing.T) {
+
+	r := makeTestRegistrator()
+
+	g := r.Group()
+	h, err := g.Middleware(func(*ctx.BaseCtx) bool { return true })
+	if err != nil {
+		t.Fatalf("Middleware: unexpected error: %v", err)
+	}
+
+	// Group middleware with no routes yet.
+	if ok, err := h.Unregister(); !ok || err != nil {
+		t.Fatalf("Unregister: got %v, %v, want true, nil", ok, err)
+	}
+	if ok, err := h.Unregister(); ok || err != nil {
+		t.Fatalf("second Unregister: got %v, %v, want false, nil", ok, err)
+	}
+
+	if _, err := g.Complex(testTypeCommand, "/start", nil).Handler(func(*ctx.BaseCtx) {}); err != nil {
+		t.Fatalf("Handler: unexpected error: %v", err)
+	}
+	if mws := r.MatchCtx(makeTestCtx(testTypeCommand, "/start"), true); len(mws) != 0 {
+		t.Fatalf("unregistered group middleware is matched")
+	}
+
+	h, err = r.RegisterMainHandler(func(*ctx.BaseCtx) {})
+	if err != nil {
+		t.Fatalf("RegisterMainHandler: unexpected error: %v", err)
+	}
+
+	r.Freeze()
+	if ok, err := h.Unregister(); ok || err == nil || err.Code() != ECFrozen {
+		t.Fatalf("Unregister of frozen: got %v, %v, want false, ECFrozen", ok, err)
+	}
+}
+
+func TestLegacyRegistration(t *testing.T) {
+
+	r := makeTestRegistrator(ParamStrict(true))
+	handler := func(*ctx.BaseCtx) {}
+
+	if err := r.Complex(testTypeCommand, "/start", nil).Handler(handler); err != nil {
+		t.Fatalf("Handler: unexpected error: %v", err)
+	}
+
+	err := r.Complex(testTypeCommand, "/help", nil).Handler(func() {})
+	if err == nil || err.Code() != ECBadHandler {
+		t.Fatalf("Handler of bad type: got %v, want EBadCallback", err)
+	}
+
+	// Conflict can not be reported by EBadCallback.
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatalf("Handler of conflicted route: no panic")
+			}
+		}()
+		_ = r.Complex(testTypeCommand, "/start", nil).Handler(handler)
+	}()
+}
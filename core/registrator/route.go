@@ -0,0 +1,169 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"unsafe"
+
+	"github.com/qioalice/devola/core/event"
+)
+
+// Route represents one rule some callback has been linked with
+// in Registrator.
+//
+// Routes are returned by Registrator.Routes method and allow to figure out
+// what callbacks are registered and under what conditions they are called.
+type Route struct {
+
+	// A rule the callback has been linked with.
+	// Type is event.CTypeInvalid for main handlers and main middlewares.
+	rule `json:",inline"`
+
+	// IsMiddleware true if the callback is middleware, false if it is handler.
+	IsMiddleware bool `json:"is_middleware"`
+
+	// IsAround true if the callback is around middleware.
+	IsAround bool `json:"is_around,omitempty"`
+
+	// IsMain true if the callback is main handler or main middleware.
+	IsMain bool `json:"is_main"`
+
+	// IsFallback true if the callback is fallback handler of views from When.
+	IsFallback bool `json:"is_fallback,omitempty"`
+
+	// The full name of callback's function.
+	Name string `json:"name"`
+
+	// The source file and line the callback is declared at.
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// Routes is a list of Route objects.
+// Has methods to represent it as JSON or as human-readable table.
+type Routes []Route
+
+// Kind returns "handler", "fallback", "middleware" or "around" depends on
+// what kind of callback r is describes.
+func (r *Route) Kind() string {
+	if r.IsFallback {
+		return "fallback"
+	}
+	if r.IsAround {
+		return "around"
+	}
+	if r.IsMiddleware {
+		return "middleware"
+	}
+	return "handler"
+}
+
+// String returns a string representation of route.
+func (r *Route) String() string {
+
+	if r == nil {
+		return ""
+	}
+
+	s := r.Kind() + " " + r.Name + " (" + r.File + ":" + fmt.Sprint(r.Line) + ")"
+	if r.IsMain {
+		return s + ", Main"
+	}
+	if r.IsFallback {
+		return s + ", " + strings.Join(*(*[]string)(unsafe.Pointer(&r.When)), ", ")
+	}
+
+	return s + ", " + r.rule.String()
+}
+
+// JSON returns an indented JSON representation of rs.
+func (rs Routes) JSON() ([]byte, error) {
+	return json.MarshalIndent(rs, "", "  ")
+}
+
+// String returns a human-readable table of rs
+// (one route per line, columns are aligned).
+func (rs Routes) String() string {
+
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(w, "KIND\tTYPE\tPATTERN\tDATA\tWHEN\tWHERE\tCALLBACK\tLOCATION")
+
+	for i := range rs {
+		r := &rs[i]
+
+		typ, pattern, data, when := "*", "", "", ""
+		switch {
+		case r.IsFallback:
+			when = strings.Join(*(*[]string)(unsafe.Pointer(&r.When)), ", ")
+		case !r.IsMain:
+			typ, pattern = r.Type.String(), r.Pattern.String()
+			data = fmt.Sprintf("%q", string(r.Data))
+			for _, alias := range r.Aliases {
+				data += fmt.Sprintf(" | %q", string(alias))
+			}
+			when = strings.Join(*(*[]string)(unsafe.Pointer(&r.When)), ", ")
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s:%d\n",
+			r.Kind(), typ, pattern, data, when, predicatesString(r.Predicates), r.Name, r.File, r.Line)
+	}
+
+	_ = w.Flush()
+	return b.String()
+}
+
+// Routes returns all registered routes (callbacks and rules they're linked with)
+// in the order they has been registered.
+//
+// It's safe to call Routes concurrently with registration.
+func (r *Registrator) Routes() Routes {
+
+	registrations := r.current().registrations
+	routes := make(Routes, 0, len(registrations))
+
+	for _, reg := range registrations {
+
+		if reg.rules == nil {
+			routes = append(routes, makeRoute(reg, nil))
+			continue
+		}
+
+		for i := range reg.rules {
+			routes = append(routes, makeRoute(reg, &reg.rules[i]))
+		}
+	}
+
+	return routes
+}
+
+// makeRoute creates a new Route object that describes rule of reg.
+// rule must be nil if reg is registration of main handler or main middleware.
+func makeRoute(reg *registration, rule *rule) Route {
+
+	route := Route{
+		IsMiddleware: reg.isMiddleware,
+		IsAround:     reg.cb.IsAround(),
+		IsFallback:   reg.isFallback,
+		Name:         reg.cb.name,
+		File:         reg.cb.file,
+		Line:         reg.cb.line,
+	}
+
+	if rule == nil {
+		route.IsMain = true
+		route.rule = *makeRule(event.CTypeInvalid, event.CDataNil, nil)
+	} else {
+		route.rule = *rule
+	}
+
+	return route
+}
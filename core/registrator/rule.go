@@ -6,6 +6,7 @@
 package registrator
 
 import (
+	"regexp"
 	"strings"
 	"unsafe"
 
@@ -27,6 +28,26 @@ type rule struct {
 	// the registering event will be handled ONLY WHEN current session's View ID
 	// is the same as any View ID from this field.
 	When []view.ID `json:"when,omitempty"`
+
+	// The way using which event data of this rule is compared with
+	// the data of occurred event (exact match if not specified).
+	Pattern PatternKind `json:"pattern,omitempty"`
+
+	// Additional conditions the context of occurred event must satisfy
+	// (chat type, sender role, custom ones). See Registrator.Where.
+	Predicates []Predicate `json:"where,omitempty"`
+
+	// Additional event data the callback is linked with (see Registrator.Alias).
+	// Used only by exact and prefix rules.
+	Aliases []event.Data `json:"aliases,omitempty"`
+
+	// Description of rule for help message and command menu
+	// (see Registrator.Describe). Nil if rule is not described.
+	Help *Help `json:"help,omitempty"`
+
+	// Compiled regular expression of glob or regex pattern.
+	// Nil for exact and prefix patterns.
+	re *regexp.Regexp
 }
 
 // String returns a string representation of rule.
@@ -38,12 +59,25 @@ func (r *rule) String() string {
 
 	s := r.Event.String()
 
+	if r.Pattern != PatternExact {
+		s += ", Pattern: " + r.Pattern.String()
+	}
+
+	if len(r.Aliases) != 0 {
+		ss := *(*[]string)(unsafe.Pointer(&r.Aliases))
+		s += ", Aliases: " + strings.Join(ss, ", ")
+	}
+
 	// Encode when if it's not empty.
 	if len(r.When) != 0 {
 		ss := *(*[]string)(unsafe.Pointer(&r.When))
 		s += ", " + strings.Join(ss, ", ")
 	}
 
+	if len(r.Predicates) != 0 {
+		s += ", Where: " + predicatesString(r.Predicates)
+	}
+
 	return s
 }
 
@@ -55,3 +89,15 @@ func makeRule(typ event.Type, data event.Data, when []view.ID) *rule {
 	r.When = when
 	return &r
 }
+
+// makePatternRule creates a new rule object the same way as makeRule does
+// but also saves pattern kind and compiled regular expression of pattern
+// (regex patterns are anchored, see PatternRegex).
+func makePatternRule(typ event.Type, kind PatternKind, source string, re *regexp.Regexp, when []view.ID) *rule {
+	r := makeRule(typ, event.Data(source), when)
+	if kind == PatternRegex && re != nil {
+		re = anchorRegex(re)
+	}
+	r.Pattern, r.re = kind, re
+	return r
+}
@@ -0,0 +1,205 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"github.com/qioalice/devola/core/ctx"
+	"github.com/qioalice/devola/core/event"
+	"github.com/qioalice/devola/core/view"
+)
+
+// storage is an immutable snapshot of all callbacks registered in Registrator.
+//
+// storage object is never changed after it has been built and published
+// by Registrator. Each registration or unregistration builds a new storage
+// object from the list of registrations (copy-on-write),
+// so the reading of storage (Match) requires no locks.
+type storage struct {
+
+	// All registrations in the order they has been made.
+	// Each storage object is built by replaying this list.
+	registrations []*registration
+
+	// There is 3 important entity using which you can get or store any callback:
+	// event type (event.Type), event data (event.Data) and view ID encoded (view.IDEnc),
+	// where
+	// event type is the type of occurred event,
+	// event data is the body of occurred event,
+	// view ID encoded is the current View ID in the session associated with occurred event.
+	//
+	// By default you should determine all these 3 things for each callback
+	// at the registering operation and then use them to get registered callback.
+	//
+	// But it is possible that you don't use sessions (and you don't have View ID)
+	// for example, or it's meaningless to determine events by its data
+	// because it is empty or too complex and different (for example if it is
+	// text message and text message can be absolutely any), or you want to
+	// register or get the callback that will be called for all untyped events, etc.
+	//
+	// Because of that there is 5 sections of storage.
+	//
+	// There is also 6th section for callbacks registered by pattern rules
+	// (prefix, glob, regex). It is checked only if no callbacks has been found
+	// in the sections above (or before 4 and 5 sections for "simple" types,
+	// because they are independent by event data and match anything).
+	//
+	// And the 7th section is for fallback handlers of views (see Registrator.Fallback).
+
+	// [ 1 SECTION ]
+	// handlers, middlewares storage:
+	// occurred event type -> current View ID -> occurred event data.
+	handlersWhen    map[event.Type]map[view.IDEnc]map[event.Data][]*Callback
+	middlewaresWhen map[event.Type]map[view.IDEnc]map[event.Data][]*Callback
+
+	// [ 2 SECTION ]
+	// handlers, middlewares storage:
+	// occurred event type -> occurred event data.
+	handlersJust    map[event.Type]map[event.Data][]*Callback
+	middlewaresJust map[event.Type]map[event.Data][]*Callback
+
+	// [ 3 SECTION ]
+	// main handlers, middlewares storage.
+	handlersMain    []*Callback
+	middlewaresMain []*Callback
+
+	// [ 4 SECTION ]
+	// occurred event type -> current View ID.
+	handlerTextWhen     map[event.Type]map[view.IDEnc][]*Callback
+	middlewaresTextWhen map[event.Type]map[view.IDEnc][]*Callback
+
+	// [ 5 SECTION ]
+	// occurred event type.
+	handlerTextJust     map[event.Type][]*Callback
+	middlewaresTextJust map[event.Type][]*Callback
+
+	// [ 6 SECTION ]
+	// occurred event type -> patterns in order they has been registered.
+	handlersPattern    map[event.Type][]*patternSlot
+	middlewaresPattern map[event.Type][]*patternSlot
+
+	// [ 7 SECTION ]
+	// fallback handlers storage:
+	// current View ID -> handlers.
+	// Checked only if no handlers has been found in the sections above.
+	handlersFallback map[view.IDEnc][]*Callback
+
+	// Prefix trees of 6th section's slots (see patternIndex).
+	// Built by Registrator.Freeze, nil until then.
+	patternIndexes map[patternIndexKey]*patternIndex
+}
+
+// registration represents one successful Handler, Middleware,
+// MainHandler, MainMiddleware or Fallback call: callback and rules it is linked with.
+type registration struct {
+
+	// The registered callback.
+	// Also it's a unique identifier of registration, because
+	// a new Callback object is created for each registration.
+	cb *Callback
+
+	// Is cb a middleware or handler.
+	isMiddleware bool
+
+	// Is cb a fallback handler.
+	// If it's so, rules has only one rule with event.CTypeInvalid as type
+	// and View IDs cb is fallback of.
+	isFallback bool
+
+	// The handler group middleware cb is registered for (see Group).
+	// Registration is removed with this handler. Nil if cb is not group middleware.
+	scope *Callback
+
+	// A copy of accumulated rules cb has been linked with.
+	// Nil if cb is main handler or main middleware.
+	rules []rule
+
+	// Each rule with each its View ID encoded.
+	// Calculated once at the registration, so the rebuilding of storage
+	// doesn't require a View ID converter.
+	targets []target
+}
+
+// target is the rule with one of its event data (rule's data or alias)
+// and one of its View ID encoded (or view.CIDEncNil if rule has no When).
+type target struct {
+	rule   *rule
+	data   event.Data
+	viewID view.IDEnc
+
+	// The callback that is saved to the storage for this target:
+	// registered callback itself or its guarded copy if rule has predicates.
+	cb *Callback
+}
+
+// makeStorage creates a new storage object and saves all callbacks
+// of registrations in it (in the same order).
+func (r *Registrator) makeStorage(registrations []*registration) *storage {
+
+	s := &storage{registrations: registrations}
+
+	for _, reg := range registrations {
+
+		if reg.rules == nil {
+			r.access(s, reg.cb, event.CTypeInvalid, event.CDataNil, view.CIDEncNil, reg.isMiddleware)
+			continue
+		}
+
+		if reg.isFallback {
+			if s.handlersFallback == nil {
+				s.handlersFallback = make(map[view.IDEnc][]*Callback)
+			}
+			for _, t := range reg.targets {
+				s.handlersFallback[t.viewID] = append(s.handlersFallback[t.viewID], t.cb)
+			}
+			continue
+		}
+
+		for _, t := range reg.targets {
+			r.saveRule(s, t.cb, t.rule, t.data, t.viewID, reg.isMiddleware)
+		}
+	}
+
+	return s
+}
+
+// matchPattern returns callbacks of the first pattern slot that is registered
+// for typ and viewID, which pattern is matched by data and which callbacks
+// are allowed by c and handlers (see filter), and captures.
+//
+// Only slots which literal prefix is a prefix of data are checked
+// if s has pattern indexes (see Registrator.Freeze).
+func (s *storage) matchPattern(c *ctx.BaseCtx, handlers []*Callback, typ event.Type, data event.Data, viewID view.IDEnc, isMiddleware bool) ([]*Callback, event.Captures) {
+
+	if s.patternIndexes != nil {
+		idx := s.patternIndexes[patternIndexKey{typ, viewID, isMiddleware}]
+		if idx == nil {
+			return nil, nil
+		}
+		var buf [8]indexedSlot
+		for _, candidate := range idx.candidates(data, buf[:0]) {
+			if cbs, captures := candidate.slot.matchCallbacks(c, handlers, data); len(cbs) != 0 {
+				return cbs, captures
+			}
+		}
+		return nil, nil
+	}
+
+	slots := s.handlersPattern[typ]
+	if isMiddleware {
+		slots = s.middlewaresPattern[typ]
+	}
+
+	for _, slot := range slots {
+		if slot.viewID != viewID {
+			continue
+		}
+		if cbs, captures := slot.matchCallbacks(c, handlers, data); len(cbs) != 0 {
+			return cbs, captures
+		}
+	}
+
+	return nil, nil
+}
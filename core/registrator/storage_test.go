@@ -0,0 +1,86 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/qioalice/devola/core/ctx"
+	"github.com/qioalice/devola/core/event"
+	"github.com/qioalice/devola/core/view"
+)
+
+// The number of routes of each kind registered by makeBenchRegistrator.
+const benchRoutes = 500
+
+// makeBenchRegistrator returns a new Registrator with benchRoutes exact routes
+// (a half of them in the "bench" view), prefix and glob routes of
+// testTypeCommand, main middleware and the View ID encoded of "bench" view.
+func makeBenchRegistrator(b *testing.B) (*Registrator, view.IDEnc) {
+
+	r := makeTestRegistrator()
+	handler := func(*ctx.BaseCtx) {}
+
+	must := func(err *EBadCallback) {
+		if err != nil {
+			b.Fatalf("unexpected registration error: %v", err)
+		}
+	}
+
+	for i := 0; i < benchRoutes; i++ {
+		var when []string
+		if i%2 == 0 {
+			when = []string{"bench"}
+		}
+		must(r.Complex(testTypeCommand, fmt.Sprintf("/exact%d", i), when).Handler(handler))
+		must(r.Prefix(testTypeCommand, fmt.Sprintf("/prefix%d ", i), nil).Handler(handler))
+		must(r.Glob(testTypeCommand, fmt.Sprintf("/glob%d/{id}", i), nil).Handler(handler))
+	}
+	must(r.MainMiddleware(func(*ctx.BaseCtx) bool { return true }))
+
+	return r, r.converter.Encode("bench")
+}
+
+// benchmarkMatch runs exact, prefix and glob sub-benchmarks of Match
+// against r, routes of which are made by makeBenchRegistrator.
+func benchmarkMatch(b *testing.B, r *Registrator, viewID view.IDEnc) {
+
+	cases := []struct {
+		name   string
+		data   event.Data
+		viewID view.IDEnc
+	}{
+		{"exact", "/exact250", viewID},
+		{"exact-noview", "/exact251", viewID},
+		{"prefix", "/prefix250 arg", viewID},
+		{"glob", "/glob250/42", viewID},
+	}
+
+	for _, c := range cases {
+		if len(r.Match(testTypeCommand, c.data, c.viewID, false)) == 0 {
+			b.Fatalf("%s: %q is not matched", c.name, c.data)
+		}
+		b.Run(c.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				r.Match(testTypeCommand, c.data, c.viewID, false)
+				r.Match(testTypeCommand, c.data, c.viewID, true)
+			}
+		})
+	}
+}
+
+func BenchmarkMatch(b *testing.B) {
+	r, viewID := makeBenchRegistrator(b)
+	benchmarkMatch(b, r, viewID)
+}
+
+func BenchmarkMatchFrozen(b *testing.B) {
+	r, viewID := makeBenchRegistrator(b)
+	r.Freeze()
+	benchmarkMatch(b, r, viewID)
+}
@@ -0,0 +1,130 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"sort"
+
+	"github.com/qioalice/devola/core/ctx"
+	"github.com/qioalice/devola/core/event"
+	"github.com/qioalice/devola/core/view"
+)
+
+// Suggest returns up to n registered event data of typ which are the closest
+// to data by edit distance, the closest first.
+//
+// Only handlers' exact rules (and their aliases) registered for viewID
+// or without View ID are considered. Event data which edit distance to data
+// is more than a third of data's length (plus one) are not returned.
+// Returns nil for "simple" types.
+//
+// It allows to build an "unknown command, did you mean ..." reply
+// without maintaining a list of commands manually.
+func (r *Registrator) Suggest(typ event.Type, data event.Data, viewID view.IDEnc, n int) []event.Data {
+
+	if n <= 0 || typ == event.CTypeInvalid || r.simplesChecker(typ) {
+		return nil
+	}
+
+	// Sessions can keep encoded View IDs of renamed views.
+	viewID = r.converter.Canonical(viewID)
+
+	type candidate struct {
+		data     event.Data
+		distance int
+	}
+
+	folded := []rune(string(r.foldCase(typ, data)))
+	maxDistance := len(folded)/3 + 1
+
+	var candidates []candidate
+	seen := make(map[event.Data]struct{})
+
+	for _, reg := range r.current().registrations {
+		if reg.isMiddleware || reg.isFallback {
+			continue
+		}
+		for i := range reg.targets {
+			t := &reg.targets[i]
+			if t.rule.Type != typ || t.rule.Pattern != PatternExact ||
+				t.viewID != view.CIDEncNil && t.viewID != viewID {
+				continue
+			}
+			if _, found := seen[t.data]; found {
+				continue
+			}
+			seen[t.data] = struct{}{}
+			distance := editDistance(folded, []rune(string(r.foldCase(typ, t.data))))
+			if distance <= maxDistance {
+				candidates = append(candidates, candidate{t.data, distance})
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+
+	var suggestions []event.Data
+	for _, c := range candidates {
+		suggestions = append(suggestions, c.data)
+	}
+
+	return suggestions
+}
+
+// SuggestCtx is the same as Suggest but takes event type, event data and
+// View ID encoded from c.
+func (r *Registrator) SuggestCtx(c *ctx.BaseCtx, n int) []event.Data {
+	if c == nil {
+		return nil
+	}
+	return r.Suggest(c.Event.Type, c.Event.Data, c.Session.ViewIDEncoded, n)
+}
+
+// editDistance returns the number of insertions, deletions, substitutions
+// and transpositions of adjacent chars required to change a to b
+// (optimal string alignment distance).
+func editDistance(a, b []rune) int {
+
+	// 3 rows are enough: the current one and two previous (for transpositions).
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if d := prev[j] + 1; d < cur[j] {
+				cur[j] = d
+			}
+			if d := cur[j-1] + 1; d < cur[j] {
+				cur[j] = d
+			}
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				if d := prev2[j-2] + 1; d < cur[j] {
+					cur[j] = d
+				}
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+
+	return prev[len(b)]
+}
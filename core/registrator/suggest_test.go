@@ -0,0 +1,48 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/qioalice/devola/core/ctx"
+	"github.com/qioalice/devola/core/errors"
+	"github.com/qioalice/devola/core/event"
+)
+
+func TestSuggestAndCommandsOfAliasedView(t *testing.T) {
+
+	r := makeTestRegistrator()
+	conv := r.converter
+
+	// A session keeps the encoded View ID of "menu" that is renamed later.
+	if code := conv.Register("menu"); code != errors.ECOK {
+		t.Fatalf("Register: unexpected code %v", code)
+	}
+	oldViewID := conv.Encode("menu")
+
+	err := r.Complex(testTypeCommand, "/start", []string{"main"}).
+		Describe("Starts the bot", "/start", "menu").
+		Handler(func(*ctx.BaseCtx) {})
+	if err != nil {
+		t.Fatalf("Handler: unexpected error: %v", err)
+	}
+
+	if code := conv.Alias("menu", "main"); code != errors.ECOK {
+		t.Fatalf("Alias: unexpected code %v", code)
+	}
+
+	wantSuggestions := []event.Data{"/start"}
+	if got := r.Suggest(testTypeCommand, "/strat", oldViewID, 3); !reflect.DeepEqual(got, wantSuggestions) {
+		t.Errorf("Suggest = %q, want %q", got, wantSuggestions)
+	}
+
+	wantCommands := Commands{{Command: "/start", Description: "Starts the bot", Usage: "/start"}}
+	if got := r.Commands(testTypeCommand, oldViewID, nil); !reflect.DeepEqual(got, wantCommands) {
+		t.Errorf("Commands = %+v, want %+v", got, wantCommands)
+	}
+}
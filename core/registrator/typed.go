@@ -0,0 +1,161 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package registrator
+
+import (
+	"reflect"
+	"regexp"
+	"unsafe"
+
+	"github.com/qioalice/devola/core/errors"
+	"github.com/qioalice/devola/core/event"
+	"github.com/qioalice/devola/core/sys/fn"
+	"github.com/qioalice/devola/core/view"
+)
+
+// Typed is a typed front of Registrator for context type C.
+//
+// Unlike Registrator's methods that take handlers and middlewares
+// as interface{} and check their types at runtime,
+// Typed's methods take "func(*C)" and "func(*C) bool" directly,
+// so a callback of wrong type is a compile time error.
+//
+// Typed object shares accumulated rules and storage with its Registrator
+// (so the rules must be accumulated by one goroutine, see Registrator).
+// Use For function to get it.
+type Typed[C any] struct {
+	r *Registrator
+}
+
+// For returns a typed front of r for context type C.
+//
+// C must be the same type as backend Ctx or the same as your context extender
+// returns, if you extends the context (the same type that is used by
+// Registrator's RegenerateRequiredTypes). Otherwise each registration
+// returns a not nil EBadCallback error object.
+func For[C any](r *Registrator) *Typed[C] {
+	return &Typed[C]{r: r}
+}
+
+// Registrator returns the Registrator t is a front of.
+func (t *Typed[C]) Registrator() *Registrator {
+	return t.r
+}
+
+// Simple is the same as Registrator.Simple.
+func (t *Typed[C]) Simple(typ event.Type, when []string) *Typed[C] {
+	t.r.Simple(typ, when)
+	return t
+}
+
+// Complex is the same as Registrator.Complex.
+func (t *Typed[C]) Complex(typ event.Type, what string, when []string) *Typed[C] {
+	t.r.Complex(typ, what, when)
+	return t
+}
+
+// Prefix is the same as Registrator.Prefix.
+func (t *Typed[C]) Prefix(typ event.Type, prefix string, when []string) *Typed[C] {
+	t.r.Prefix(typ, prefix, when)
+	return t
+}
+
+// Glob is the same as Registrator.Glob.
+func (t *Typed[C]) Glob(typ event.Type, glob string, when []string) *Typed[C] {
+	t.r.Glob(typ, glob, when)
+	return t
+}
+
+// Regex is the same as Registrator.Regex.
+func (t *Typed[C]) Regex(typ event.Type, expr *regexp.Regexp, when []string) *Typed[C] {
+	t.r.Regex(typ, expr, when)
+	return t
+}
+
+// Where is the same as Registrator.Where.
+func (t *Typed[C]) Where(predicates ...Predicate) *Typed[C] {
+	t.r.Where(predicates...)
+	return t
+}
+
+// Alias is the same as Registrator.Alias.
+func (t *Typed[C]) Alias(aliases ...string) *Typed[C] {
+	t.r.Alias(aliases...)
+	return t
+}
+
+// Describe is the same as Registrator.Describe.
+func (t *Typed[C]) Describe(description, usage string, visibleIn ...view.ID) *Typed[C] {
+	t.r.Describe(description, usage, visibleIn...)
+	return t
+}
+
+// Handler is the same as Registrator.RegisterHandler but takes typed handler.
+func (t *Typed[C]) Handler(handler func(*C)) (*Handle, errors.Error) {
+	return t.r.saveCallback(t.handler(handler), reflect.TypeOf(handler), kindHandler, false)
+}
+
+// Middleware is the same as Registrator.RegisterMiddleware but takes typed middleware.
+func (t *Typed[C]) Middleware(middleware func(*C) bool) (*Handle, errors.Error) {
+	return t.r.saveCallback(t.middleware(middleware), reflect.TypeOf(middleware), kindMiddleware, false)
+}
+
+// MainHandler is the same as Registrator.RegisterMainHandler but takes typed handler.
+func (t *Typed[C]) MainHandler(handler func(*C)) (*Handle, errors.Error) {
+	return t.r.saveCallback(t.handler(handler), reflect.TypeOf(handler), kindHandler, true)
+}
+
+// MainMiddleware is the same as Registrator.RegisterMainMiddleware
+// but takes typed middleware.
+func (t *Typed[C]) MainMiddleware(middleware func(*C) bool) (*Handle, errors.Error) {
+	return t.r.saveCallback(t.middleware(middleware), reflect.TypeOf(middleware), kindMiddleware, true)
+}
+
+// Around is the same as Registrator.Around but takes typed around middleware.
+func (t *Typed[C]) Around(middleware func(*C, func())) (*Handle, errors.Error) {
+	return t.r.saveCallback(t.around(middleware), reflect.TypeOf(middleware), kindAround, false)
+}
+
+// MainAround is the same as Registrator.MainAround
+// but takes typed around middleware.
+func (t *Typed[C]) MainAround(middleware func(*C, func())) (*Handle, errors.Error) {
+	return t.r.saveCallback(t.around(middleware), reflect.TypeOf(middleware), kindAround, true)
+}
+
+// Fallback is the same as Registrator.Fallback but takes typed handler.
+func (t *Typed[C]) Fallback(handler func(*C), when []string) (*Handle, errors.Error) {
+	return t.r.saveFallback(t.handler(handler), reflect.TypeOf(handler), *(*[]view.ID)(unsafe.Pointer(&when)))
+}
+
+// handler wraps handler to the Callback or returns nil if handler is nil.
+func (*Typed[C]) handler(handler func(*C)) *Callback {
+	if handler == nil {
+		return nil
+	}
+	c := &Callback{handler: func(ctx unsafe.Pointer) { handler((*C)(ctx)) }}
+	c.name, c.file, c.line = fn.Describe(handler)
+	return c
+}
+
+// middleware wraps middleware to the Callback or returns nil if middleware is nil.
+func (*Typed[C]) middleware(middleware func(*C) bool) *Callback {
+	if middleware == nil {
+		return nil
+	}
+	c := &Callback{middleware: func(ctx unsafe.Pointer) bool { return middleware((*C)(ctx)) }}
+	c.name, c.file, c.line = fn.Describe(middleware)
+	return c
+}
+
+// around wraps around middleware to the Callback or returns nil if it is nil.
+func (*Typed[C]) around(middleware func(*C, func())) *Callback {
+	if middleware == nil {
+		return nil
+	}
+	c := &Callback{around: func(ctx unsafe.Pointer, next func()) { middleware((*C)(ctx), next) }}
+	c.name, c.file, c.line = fn.Describe(middleware)
+	return c
+}
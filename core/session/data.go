@@ -0,0 +1,276 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package session
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"github.com/qioalice/devola/core/errors"
+)
+
+// Data is the typed key-value bag of session: the state of flow
+// (cart, form answers, pagination cursors, etc) that is kept and
+// serialized with Session.
+//
+// Values are kept encoded, use Get and Set functions to access them:
+//
+//	session.Set(s, "page", 2)
+//	page, code := session.Get[int](s, "page")
+//
+// Values of predeclared types (bool, numbers, string) and []byte
+// are encoded as JSON. Values of all other types require an explicitly
+// registered codec (see RegisterCodec, RegisterJSON).
+// The size of values is limited (see SetDataLimits).
+type Data map[string]json.RawMessage
+
+// codec is the type-erased encoder and decoder of values of some type.
+type codec struct {
+	encode func(v interface{}) ([]byte, error)
+	decode func(data []byte) (interface{}, error)
+
+	// Encoded values are JSON (kept as is), otherwise they are kept
+	// as JSON strings (base64).
+	isJSON bool
+}
+
+// Predefined constants.
+const (
+
+	// Default size limits of Data (see SetDataLimits).
+	cDataMaxValue = 4 << 10
+	cDataMaxTotal = 16 << 10
+)
+
+// codecs are registered codecs of types (see RegisterCodec)
+// and size limits of Data (see SetDataLimits).
+var codecs = struct {
+	mu       sync.RWMutex
+	m        map[reflect.Type]*codec
+	maxValue int
+	maxTotal int
+}{
+	m:        make(map[reflect.Type]*codec),
+	maxValue: cDataMaxValue,
+	maxTotal: cDataMaxTotal,
+}
+
+// RegisterCodec registers encoder and decoder of values of type T
+// for Data. Encoded values can be any bytes.
+// The codec of T registered before is replaced.
+func RegisterCodec[T any](encode func(v T) ([]byte, error), decode func(data []byte) (T, error)) {
+	registerCodec[T](&codec{
+		encode: func(v interface{}) ([]byte, error) { return encode(v.(T)) },
+		decode: func(data []byte) (interface{}, error) { return decode(data) },
+	})
+}
+
+// RegisterJSON registers JSON codec of values of type T for Data
+// (encoding/json is used).
+// The codec of T registered before is replaced.
+func RegisterJSON[T any]() {
+	registerCodec[T](jsonCodec[T]())
+}
+
+// SetDataLimits sets the max size of one encoded value and the max total
+// size of all keys and encoded values of Data (4 KiB and 16 KiB by default).
+// Non-positive values are ignored.
+func SetDataLimits(maxValue, maxTotal int) {
+
+	codecs.mu.Lock()
+	defer codecs.mu.Unlock()
+
+	if maxValue > 0 {
+		codecs.maxValue = maxValue
+	}
+	if maxTotal > 0 {
+		codecs.maxTotal = maxTotal
+	}
+}
+
+// Get returns the value of type T saved in s's Data under key.
+//
+// Returns ECDataNotFound if there is no such key, ECNoCodec if there is
+// no codec for T or ECBadValue if the value can't be decoded as T.
+func Get[T any](s *Session, key string) (T, errors.Code) {
+
+	var zero T
+
+	raw, found := s.Data[key]
+	if !found {
+		return zero, ECDataNotFound
+	}
+
+	c := codecOf[T]()
+	if c == nil {
+		return zero, ECNoCodec
+	}
+
+	data := []byte(raw)
+	if !c.isJSON {
+		if err := json.Unmarshal(raw, &data); err != nil {
+			return zero, ECBadValue
+		}
+	}
+
+	v, err := c.decode(data)
+	if err != nil {
+		return zero, ECBadValue
+	}
+
+	return v.(T), errors.ECOK
+}
+
+// Set saves v of type T to s's Data under key (replaces existed value).
+//
+// Returns ECNoCodec if there is no codec for T, ECBadValue if v can't be
+// encoded or ECDataTooLarge if encoded v or the whole Data exceeds
+// the size limits (see SetDataLimits). Nothing is changed then.
+func Set[T any](s *Session, key string, v T) errors.Code {
+
+	c := codecOf[T]()
+	if c == nil {
+		return ECNoCodec
+	}
+
+	data, err := c.encode(v)
+	if err != nil {
+		return ECBadValue
+	}
+
+	if !c.isJSON {
+		if data, err = json.Marshal(data); err != nil {
+			return ECBadValue
+		}
+	}
+
+	codecs.mu.RLock()
+	maxValue, maxTotal := codecs.maxValue, codecs.maxTotal
+	codecs.mu.RUnlock()
+
+	if len(data) > maxValue ||
+		s.Data.Size()-s.Data.sizeOf(key)+len(key)+len(data) > maxTotal {
+		return ECDataTooLarge
+	}
+
+	if s.Data == nil {
+		s.Data = make(Data)
+	}
+
+	s.Data[key] = data
+	return errors.ECOK
+}
+
+// Has reports whether there is a value under key.
+func (d Data) Has(key string) bool {
+	_, found := d[key]
+	return found
+}
+
+// Delete removes the value under key.
+func (d Data) Delete(key string) {
+	delete(d, key)
+}
+
+// Size returns the total size of all keys and encoded values of d.
+func (d Data) Size() int {
+	size := 0
+	for key, raw := range d {
+		size += len(key) + len(raw)
+	}
+	return size
+}
+
+// sizeOf returns the size of key and its encoded value
+// or 0 if there is no such key.
+func (d Data) sizeOf(key string) int {
+	if raw, found := d[key]; found {
+		return len(key) + len(raw)
+	}
+	return 0
+}
+
+// clone returns a deep copy of d.
+func (d Data) clone() Data {
+
+	if d == nil {
+		return nil
+	}
+
+	cloned := make(Data, len(d))
+	for key, raw := range d {
+		cloned[key] = append(json.RawMessage(nil), raw...)
+	}
+	return cloned
+}
+
+// codecOf returns the codec of T: registered one, JSON one for predeclared
+// types and []byte, or nil if there is no codec for T.
+func codecOf[T any]() *codec {
+
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+
+	codecs.mu.RLock()
+	c := codecs.m[typ]
+	codecs.mu.RUnlock()
+
+	if c != nil {
+		return c
+	}
+
+	if isPredeclared(typ) {
+		return jsonCodec[T]()
+	}
+
+	return nil
+}
+
+// registerCodec saves c as the codec of T.
+func registerCodec[T any](c *codec) {
+
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+
+	codecs.mu.Lock()
+	codecs.m[typ] = c
+	codecs.mu.Unlock()
+}
+
+// jsonCodec returns a new JSON codec of T.
+func jsonCodec[T any]() *codec {
+	return &codec{
+		encode: func(v interface{}) ([]byte, error) { return json.Marshal(v) },
+		decode: func(data []byte) (interface{}, error) {
+			var v T
+			err := json.Unmarshal(data, &v)
+			return v, err
+		},
+		isJSON: true,
+	}
+}
+
+// isPredeclared reports whether typ is predeclared bool, number
+// or string type or []byte.
+func isPredeclared(typ reflect.Type) bool {
+
+	if typ == reflect.TypeOf([]byte(nil)) {
+		return true
+	}
+
+	if typ.PkgPath() != "" {
+		return false
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,181 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package session_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/qioalice/devola/core/errors"
+	"github.com/qioalice/devola/core/session"
+)
+
+// testCart is a value with registered JSON codec.
+type testCart struct {
+	Items []string `json:"items"`
+	Total int      `json:"total"`
+}
+
+// testPoint is a value with registered non-JSON codec.
+type testPoint struct {
+	X, Y byte
+}
+
+func init() {
+	session.RegisterJSON[testCart]()
+	session.RegisterCodec(
+		func(p testPoint) ([]byte, error) { return []byte{p.X, p.Y}, nil },
+		func(data []byte) (testPoint, error) {
+			if len(data) != 2 {
+				return testPoint{}, fmt.Errorf("bad point: %v", data)
+			}
+			return testPoint{data[0], data[1]}, nil
+		},
+	)
+}
+
+// setDataLimits sets the size limits of Data until t is finished.
+func setDataLimits(t *testing.T, maxValue, maxTotal int) {
+	session.SetDataLimits(maxValue, maxTotal)
+	t.Cleanup(func() { session.SetDataLimits(4<<10, 16<<10) })
+}
+
+func TestDataLimits(t *testing.T) {
+
+	setDataLimits(t, 16, 32)
+
+	tests := []struct {
+		name  string
+		data  session.Data
+		key   string
+		value string
+		want  errors.Code
+	}{
+		{"fits", nil, "k", "short", errors.ECOK},
+		{"too large value", nil, "k", strings.Repeat("a", 15), session.ECDataTooLarge},
+		{"too large total", session.Data{"other": json.RawMessage(`"` + strings.Repeat("a", 14) + `"`)},
+			"k", strings.Repeat("a", 12), session.ECDataTooLarge},
+		{"replaced value is not counted", session.Data{"k": json.RawMessage(`"` + strings.Repeat("a", 14) + `"`)},
+			"k", strings.Repeat("b", 14), errors.ECOK},
+		{"too large replacing value", session.Data{"k": json.RawMessage(`"a"`)},
+			"k", strings.Repeat("b", 15), session.ECDataTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			s := &session.Session{Data: tt.data}
+			before := s.Clone().Data
+
+			if code := session.Set(s, tt.key, tt.value); code != tt.want {
+				t.Fatalf("Set: got code %v, want %v", code, tt.want)
+			}
+
+			if tt.want != errors.ECOK {
+				if !reflect.DeepEqual(s.Data, before) {
+					t.Fatalf("failed Set changed data: %v, want %v", s.Data, before)
+				}
+				return
+			}
+
+			if got, code := session.Get[string](s, tt.key); got != tt.value || code != errors.ECOK {
+				t.Fatalf("Get: got %q, %v, want %q", got, code, tt.value)
+			}
+		})
+	}
+}
+
+func TestDataCodecs(t *testing.T) {
+
+	s := new(session.Session)
+
+	type unregistered struct{ A int }
+	if code := session.Set(s, "u", unregistered{1}); code != session.ECNoCodec {
+		t.Fatalf("Set of unregistered type: got code %v, want ECNoCodec", code)
+	}
+
+	cart := testCart{Items: []string{"apple", "pear"}, Total: 42}
+	if code := session.Set(s, "cart", cart); code != errors.ECOK {
+		t.Fatalf("Set(cart): unexpected code %v", code)
+	}
+	if !json.Valid(s.Data["cart"]) || !bytes.Contains(s.Data["cart"], []byte(`"items"`)) {
+		t.Fatalf("JSON value is not kept as is: %s", s.Data["cart"])
+	}
+
+	// Values of non-JSON codecs are kept as base64 JSON strings.
+	if code := session.Set(s, "point", testPoint{1, 2}); code != errors.ECOK {
+		t.Fatalf("Set(point): unexpected code %v", code)
+	}
+	if got, want := string(s.Data["point"]), `"AQI="`; got != want {
+		t.Fatalf("non-JSON value is kept as %s, want %s", got, want)
+	}
+
+	if got, code := session.Get[testCart](s, "cart"); !reflect.DeepEqual(got, cart) || code != errors.ECOK {
+		t.Fatalf("Get(cart): got %+v, %v, want %+v", got, code, cart)
+	}
+	if got, code := session.Get[testPoint](s, "point"); got != (testPoint{1, 2}) || code != errors.ECOK {
+		t.Fatalf("Get(point): got %+v, %v", got, code)
+	}
+
+	if _, code := session.Get[testPoint](s, "cart"); code != session.ECBadValue {
+		t.Fatalf("Get of JSON value by non-JSON codec: got code %v, want ECBadValue", code)
+	}
+	if _, code := session.Get[int](s, "missing"); code != session.ECDataNotFound {
+		t.Fatalf("Get of missing key: got code %v, want ECDataNotFound", code)
+	}
+}
+
+func TestDataFileStoreRoundTrip(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "sessions.log")
+	fs := openFileStore(t, path)
+
+	s := &session.Session{ID: 1, ViewID: "cart"}
+	cart := testCart{Items: []string{"apple"}, Total: 7}
+
+	for key, code := range map[string]errors.Code{
+		"page":  session.Set(s, "page", 2),
+		"name":  session.Set(s, "name", "Alice"),
+		"raw":   session.Set(s, "raw", []byte{0, 255}),
+		"cart":  session.Set(s, "cart", cart),
+		"point": session.Set(s, "point", testPoint{3, 4}),
+	} {
+		if code != errors.ECOK {
+			t.Fatalf("Set(%q): unexpected code %v", key, code)
+		}
+	}
+
+	mustSave(t, fs, 1, s)
+	if err := fs.Close(); err != nil {
+		t.Fatalf("Close: unexpected error: %v", err)
+	}
+
+	loaded, found, err := openFileStore(t, path).Get(1)
+	if err != nil || !found {
+		t.Fatalf("Get after reopen: got %v, %v", found, err)
+	}
+
+	if got, _ := session.Get[int](loaded, "page"); got != 2 {
+		t.Fatalf("page: got %d, want 2", got)
+	}
+	if got, _ := session.Get[string](loaded, "name"); got != "Alice" {
+		t.Fatalf("name: got %q, want Alice", got)
+	}
+	if got, _ := session.Get[[]byte](loaded, "raw"); !bytes.Equal(got, []byte{0, 255}) {
+		t.Fatalf("raw: got %v, want [0 255]", got)
+	}
+	if got, _ := session.Get[testCart](loaded, "cart"); !reflect.DeepEqual(got, cart) {
+		t.Fatalf("cart: got %+v, want %+v", got, cart)
+	}
+	if got, _ := session.Get[testPoint](loaded, "point"); got != (testPoint{3, 4}) {
+		t.Fatalf("point: got %+v, want {3 4}", got)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package session
+
+import (
+	"sort"
+
+	"github.com/qioalice/devola/core/chat"
+	"github.com/qioalice/devola/core/view"
+)
+
+// DeprecatedSessions walks all sessions of store and returns the chats
+// which sessions still point to deprecated View IDs (aliases,
+// see view.IDConv.Alias), grouped by these View IDs (chats are sorted).
+//
+// The session points to the deprecated View ID if its current view
+// (ViewID or ViewIDEncoded) or some view of its History is an alias.
+// An alias can be removed (see view.IDConv.Unalias) when there is no
+// sessions pointing to it anymore.
+func DeprecatedSessions(store Store, idc *view.IDConv) (map[view.ID][]chat.IDT, error) {
+
+	report := make(map[view.ID][]chat.IDT)
+
+	err := store.Scan(func(idt chat.IDT, s *Session) bool {
+
+		// Each chat is reported once per deprecated View ID.
+		var seen []view.ID
+		add := func(id view.ID, idenc view.IDEnc) {
+			deprecated, ok := idc.Deprecated(id, idenc)
+			if !ok {
+				return
+			}
+			for _, id := range seen {
+				if id == deprecated {
+					return
+				}
+			}
+			seen = append(seen, deprecated)
+			report[deprecated] = append(report[deprecated], idt)
+		}
+
+		add(s.ViewID, view.CIDEncNil)
+		add(view.CIDNil, s.ViewIDEncoded)
+		for _, id := range s.History {
+			add(id, view.CIDEncNil)
+		}
+
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, chats := range report {
+		sort.Slice(chats, func(i, j int) bool { return chats[i] < chats[j] })
+	}
+
+	return report, nil
+}
@@ -0,0 +1,51 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package session_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/qioalice/devola/core/chat"
+	"github.com/qioalice/devola/core/errors"
+	"github.com/qioalice/devola/core/session"
+	"github.com/qioalice/devola/core/view"
+)
+
+func TestDeprecatedSessions(t *testing.T) {
+
+	idc := view.MakeIDConv()
+	if code := idc.Register("old_cart", "old_menu", "catalog"); code != errors.ECOK {
+		t.Fatalf("Register: unexpected error code %v", code)
+	}
+	oldCart := idc.Encode("old_cart")
+
+	for old, new := range map[view.ID]view.ID{"old_cart": "cart", "old_menu": "menu"} {
+		if code := idc.Alias(old, new); code != errors.ECOK {
+			t.Fatalf("Alias(%q, %q): unexpected error code %v", old, new, code)
+		}
+	}
+
+	store := session.MakeMemoryStore()
+	mustSave(t, store, 1, &session.Session{ViewID: "catalog"})
+	mustSave(t, store, 2, &session.Session{ViewIDEncoded: oldCart})
+	mustSave(t, store, 3, &session.Session{ViewID: "old_cart", ViewIDEncoded: oldCart})
+	mustSave(t, store, 4, &session.Session{ViewID: "catalog", History: session.History{"old_menu", "catalog", "old_menu"}})
+	mustSave(t, store, 5, &session.Session{ViewID: "cart", History: session.History{"menu"}})
+
+	report, err := session.DeprecatedSessions(store, idc)
+	if err != nil {
+		t.Fatalf("DeprecatedSessions: unexpected error: %v", err)
+	}
+
+	want := map[view.ID][]chat.IDT{
+		"old_cart": {2, 3},
+		"old_menu": {4},
+	}
+	if !reflect.DeepEqual(report, want) {
+		t.Fatalf("DeprecatedSessions: got %v, want %v", report, want)
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package session
+
+import (
+	"github.com/qioalice/devola/core/errors"
+)
+
+// Predefined error codes of session operations.
+const (
+
+	// Empty navigation history error.
+	// Returned:
+	// - From ctx.BaseCtx.Pop and ctx.BaseCtx.Back methods if there is
+	//   no previous view in session's History.
+	ECHistoryEmpty errors.Code = 21
+
+	// Data value not found error.
+	// Returned:
+	// - From Get function if there is no value under passed key.
+	ECDataNotFound errors.Code = 22
+
+	// No codec error.
+	// Returned:
+	// - From Get and Set functions if there is no codec for the type
+	//   of value (see RegisterCodec, RegisterJSON).
+	ECNoCodec errors.Code = 23
+
+	// Bad value error.
+	// Returned:
+	// - From Get function if the value can't be decoded to passed type.
+	// - From Set function if the value can't be encoded.
+	ECBadValue errors.Code = 24
+
+	// Too large data error.
+	// Returned:
+	// - From Set function if the encoded value or the whole Data exceeds
+	//   the size limits (see SetDataLimits).
+	ECDataTooLarge errors.Code = 25
+)
@@ -0,0 +1,58 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package session
+
+import (
+	"github.com/qioalice/devola/core/view"
+)
+
+// History is the navigation history of session: the views session
+// has been in before the current one (the last one is the most recent).
+//
+// It is serialized with Session, so "Back" works across restarts.
+// Use ctx.BaseCtx's Push, Replace, Pop, Reset methods to change the view
+// of session keeping its History.
+type History []view.ID
+
+// Push appends id to h. If maxDepth > 0 and h becomes longer than maxDepth,
+// the oldest views are dropped. Invalid id is ignored.
+func (h *History) Push(id view.ID, maxDepth int) {
+
+	if !id.IsValid() {
+		return
+	}
+
+	*h = append(*h, id)
+	if maxDepth > 0 && len(*h) > maxDepth {
+		*h = append((*h)[:0], (*h)[len(*h)-maxDepth:]...)
+	}
+}
+
+// Pop removes the most recent view from h and returns it.
+// Returns view.CIDNil and false if h is empty.
+func (h *History) Pop() (view.ID, bool) {
+
+	if len(*h) == 0 {
+		return view.CIDNil, false
+	}
+
+	id := (*h)[len(*h)-1]
+	*h = (*h)[:len(*h)-1]
+	return id, true
+}
+
+// Last returns the most recent view of h or view.CIDNil if h is empty.
+func (h History) Last() view.ID {
+	if len(h) == 0 {
+		return view.CIDNil
+	}
+	return h[len(h)-1]
+}
+
+// Reset removes all views from h.
+func (h *History) Reset() {
+	*h = nil
+}
@@ -0,0 +1,47 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package session
+
+// memoryParam is an alias to function that takes a MemoryStore object
+// and changes its behaviour.
+// It uses as parameters for MemoryStore constructor.
+type memoryParam func(ms *MemoryStore)
+
+// fileParam is an alias to function that takes a FileStore object
+// and changes its behaviour.
+// It uses as parameters for FileStore constructor.
+type fileParam func(fs *FileStore)
+
+// ParamShards sets the number of shards of MemoryStore (32 by default).
+// Non-positive values are ignored.
+func ParamShards(n int) memoryParam {
+	return func(ms *MemoryStore) {
+		if n > 0 {
+			ms.shards = make([]memoryShard, n)
+		}
+	}
+}
+
+// ParamCompaction sets when FileStore compacts its log: when it has more
+// than minRecords records and live sessions are less than 1/ratio of them
+// (1024 and 2 by default). Non-positive values are ignored.
+func ParamCompaction(minRecords, ratio int) fileParam {
+	return func(fs *FileStore) {
+		if minRecords > 0 {
+			fs.compactMin = minRecords
+		}
+		if ratio > 0 {
+			fs.compactRatio = ratio
+		}
+	}
+}
+
+// ParamSync enables (or disables) syncing of FileStore's log file to disk
+// after each write (disabled by default). Without it the last writes
+// can be lost on power failure (but not on process crash).
+func ParamSync(enable bool) fileParam {
+	return func(fs *FileStore) { fs.isSync = enable }
+}
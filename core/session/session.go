@@ -12,6 +12,13 @@ import (
 	"github.com/qioalice/devola/core/view"
 )
 
+// Predefined constants.
+const (
+
+	// The value of ExpirationUnixstamp of eternal (infinity) session.
+	CExpirationEternal int64 = -1
+)
+
 // Session represents some dialogue between user and server.
 //
 // Moreover session allows to represent the dialogue as the "wizard" of
@@ -31,24 +38,30 @@ type Session struct {
 	//
 	ViewIDEncoded view.IDEnc `json:"view_id_encoded"`
 
+	// The views session has been in before the current one (see History).
+	History History `json:"history,omitempty"`
+
 	//
 	SentMessages chat.MessageIDs `json:"sent_messages"`
 
 	//
 	ExpirationUnixstamp int64 `json:"expiration_unixstamp"`
+
+	// The typed key-value bag of session (see Data, Get, Set).
+	Data Data `json:"data,omitempty"`
 }
 
-// isEternal returns true if s is eternal (infinity) session.
-func (s *Session) isEternal() bool {
-	return s.ExpirationUnixstamp == -1
+// IsEternal returns true if s is eternal (infinity) session.
+func (s *Session) IsEternal() bool {
+	return s.ExpirationUnixstamp == CExpirationEternal
 }
 
-// isExpiredAt returns true if s will be expired at the stamp (should be unix timestamp).
-func (s *Session) isExpiredAt(stamp int64) bool {
-	return !s.isEternal() && s.ExpirationUnixstamp <= stamp
+// IsExpiredAt returns true if s will be expired at the stamp (should be unix timestamp).
+func (s *Session) IsExpiredAt(stamp int64) bool {
+	return !s.IsEternal() && s.ExpirationUnixstamp <= stamp
 }
 
-// isExpired returns true if s is already expired.
-func (s *Session) isExpired() bool {
-	return s.isExpiredAt(time.Now().Unix())
+// IsExpired returns true if s is already expired.
+func (s *Session) IsExpired() bool {
+	return s.IsExpiredAt(time.Now().Unix())
 }
@@ -0,0 +1,74 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package session
+
+import (
+	stderrors "errors"
+
+	"github.com/qioalice/devola/core/chat"
+)
+
+// ErrNilSession is returned by Store.Save if the saving session is nil.
+// Use Store.Delete to remove the session.
+var ErrNilSession = stderrors.New("session: nil session can not be saved")
+
+// Store is the storage of sessions: the current session of each chat.
+//
+// Implementations must be safe for concurrent use and must not keep
+// the passed and returned Session objects (only their copies),
+// so the caller can change them freely.
+// Use package storetest to check an implementation.
+type Store interface {
+
+	// Get returns the session of chat idt
+	// or nil and false if there is no such session.
+	Get(idt chat.IDT) (*Session, bool, error)
+
+	// Save saves s as the session of chat idt (replaces existed one).
+	// s must not be nil, ErrNilSession is returned otherwise.
+	Save(idt chat.IDT, s *Session) error
+
+	// SaveIf is the same as Save but saves s only if f reports true
+	// for the current session of chat idt (or for nil if there is no such
+	// session). Reports whether s has been saved. Nil f is always true.
+	//
+	// The check and the saving are atomic: the session can't be changed
+	// between them. f must not change or keep the passed session
+	// and must not call methods of the Store.
+	SaveIf(idt chat.IDT, s *Session, f func(current *Session) bool) (bool, error)
+
+	// Delete removes the session of chat idt.
+	// It's not an error if there is no such session.
+	Delete(idt chat.IDT) error
+
+	// DeleteIf is the same as Delete but removes the session of chat idt
+	// only if f reports true for it (f is not called if there is no such
+	// session). Reports whether the session has been removed.
+	// Nil f is always true.
+	//
+	// The check and the removing are atomic (see SaveIf for f restrictions).
+	DeleteIf(idt chat.IDT, f func(current *Session) bool) (bool, error)
+
+	// Scan calls f for each stored session (in no particular order)
+	// until f returns false.
+	// f must not call methods of the Store.
+	Scan(f func(idt chat.IDT, s *Session) (isContinue bool)) error
+}
+
+// Clone returns a deep copy of s.
+func (s *Session) Clone() *Session {
+
+	if s == nil {
+		return nil
+	}
+
+	cloned := *s
+	cloned.SentMessages = append(s.SentMessages[:0:0], s.SentMessages...)
+	cloned.History = append(s.History[:0:0], s.History...)
+	cloned.Data = s.Data.clone()
+
+	return &cloned
+}
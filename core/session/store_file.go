@@ -0,0 +1,415 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/qioalice/devola/core/chat"
+)
+
+// FileStore is the embedded Store that keeps sessions in a single file.
+//
+// The file is an append-only log: each Save and Delete appends one
+// JSON record (one per line). All sessions are kept in memory too,
+// so Get and Scan don't read the file. The log is replayed when FileStore
+// is opened and it is compacted (rewritten with live sessions only)
+// when it becomes too long (see ParamCompaction).
+//
+// A partially written last record (after crash) is discarded at opening,
+// a record that can't be written entirely is truncated at once.
+// FileStore is safe for concurrent use (but not for concurrent use of the
+// same file by several FileStore objects).
+type FileStore struct {
+	mu sync.RWMutex
+
+	// The path of log file and the log file itself (nil if it's closed).
+	path string
+	file *os.File
+
+	// All live sessions.
+	sessions map[chat.IDT]*Session
+
+	// The number of records in the log file
+	// and the size of all of them (the offset the next record is written at).
+	records int
+	size    int64
+
+	// Compaction thresholds (see ParamCompaction).
+	compactMin   int
+	compactRatio int
+
+	// Sync log file after each write (see ParamSync).
+	isSync bool
+}
+
+// fileRecord is one record of FileStore's log.
+// Session is nil for deleting records.
+type fileRecord struct {
+	Chat    chat.IDT `json:"chat"`
+	Session *Session `json:"session,omitempty"`
+}
+
+// Predefined constants.
+const (
+
+	// Default compaction thresholds of FileStore (see ParamCompaction).
+	cFileStoreCompactMin   = 1024
+	cFileStoreCompactRatio = 2
+)
+
+// Get implements Store.
+func (fs *FileStore) Get(idt chat.IDT) (*Session, bool, error) {
+
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	if fs.file == nil {
+		return nil, false, os.ErrClosed
+	}
+
+	s, found := fs.sessions[idt]
+	return s.Clone(), found, nil
+}
+
+// Save implements Store.
+func (fs *FileStore) Save(idt chat.IDT, s *Session) error {
+	_, err := fs.SaveIf(idt, s, nil)
+	return err
+}
+
+// SaveIf implements Store.
+func (fs *FileStore) SaveIf(idt chat.IDT, s *Session, f func(current *Session) bool) (bool, error) {
+
+	if s == nil {
+		return false, ErrNilSession
+	}
+
+	cloned := s.Clone()
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.file == nil {
+		return false, os.ErrClosed
+	}
+
+	if f != nil && !f(fs.sessions[idt]) {
+		return false, nil
+	}
+
+	if err := fs.append(fileRecord{idt, cloned}); err != nil {
+		return false, err
+	}
+
+	fs.sessions[idt] = cloned
+	return true, fs.compactIfNeeded()
+}
+
+// Delete implements Store.
+func (fs *FileStore) Delete(idt chat.IDT) error {
+	_, err := fs.DeleteIf(idt, nil)
+	return err
+}
+
+// DeleteIf implements Store.
+func (fs *FileStore) DeleteIf(idt chat.IDT, f func(current *Session) bool) (bool, error) {
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.file == nil {
+		return false, os.ErrClosed
+	}
+
+	if s, found := fs.sessions[idt]; !found || f != nil && !f(s) {
+		return false, nil
+	}
+
+	if err := fs.append(fileRecord{Chat: idt}); err != nil {
+		return false, err
+	}
+
+	delete(fs.sessions, idt)
+	return true, fs.compactIfNeeded()
+}
+
+// Scan implements Store.
+func (fs *FileStore) Scan(f func(idt chat.IDT, s *Session) (isContinue bool)) error {
+
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+
+	if fs.file == nil {
+		return os.ErrClosed
+	}
+
+	for idt, s := range fs.sessions {
+		if !f(idt, s.Clone()) {
+			break
+		}
+	}
+
+	return nil
+}
+
+// Compact rewrites the log file with live sessions only.
+func (fs *FileStore) Compact() error {
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.file == nil {
+		return os.ErrClosed
+	}
+
+	return fs.compact()
+}
+
+// Close closes the log file. FileStore can't be used after that.
+func (fs *FileStore) Close() error {
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.file == nil {
+		return os.ErrClosed
+	}
+
+	err := fs.file.Close()
+	fs.file = nil
+	return err
+}
+
+// append appends rec to the log file.
+// If rec can't be written (or synced) entirely, the log file is truncated
+// to its previous size, so there is no partial record in it.
+// fs.mu must be locked.
+func (fs *FileStore) append(rec fileRecord) error {
+
+	if fs.file == nil {
+		return os.ErrClosed
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	_, err = fs.file.Write(append(data, '\n'))
+	if err == nil && fs.isSync {
+		err = fs.file.Sync()
+	}
+
+	if err != nil {
+		return fs.rollback(err)
+	}
+
+	fs.records++
+	fs.size += int64(len(data)) + 1
+	return nil
+}
+
+// rollback truncates the log file to the size of all complete records
+// after the failed write and returns err.
+// If the log file can't be truncated, it's closed
+// (FileStore can't be used anymore, reopen it to recover).
+// fs.mu must be locked.
+func (fs *FileStore) rollback(err error) error {
+
+	truncErr := fs.file.Truncate(fs.size)
+	if truncErr == nil {
+		_, truncErr = fs.file.Seek(fs.size, io.SeekStart)
+	}
+
+	if truncErr != nil {
+		_ = fs.file.Close()
+		fs.file = nil
+		return fmt.Errorf("session: %w (log file %s is closed, can't truncate it: %v)", err, fs.path, truncErr)
+	}
+
+	return err
+}
+
+// compactIfNeeded compacts the log file if it's too long (see ParamCompaction).
+// fs.mu must be locked.
+func (fs *FileStore) compactIfNeeded() error {
+	if fs.records > fs.compactMin && len(fs.sessions)*fs.compactRatio < fs.records {
+		return fs.compact()
+	}
+	return nil
+}
+
+// compact is the Compact's core.
+// Writes live sessions to the temporary file, replaces the log file by it
+// and syncs the directory of log file, so the replacement survives a crash.
+// fs.mu must be locked.
+func (fs *FileStore) compact() error {
+
+	tmpPath := fs.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for idt, s := range fs.sessions {
+		if err = enc.Encode(fileRecord{idt, s}); err != nil {
+			break
+		}
+	}
+
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, fs.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	file, err := os.OpenFile(fs.path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		// The old file is replaced already, so FileStore can't be used anymore.
+		_ = fs.file.Close()
+		fs.file = nil
+		return err
+	}
+
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		_ = file.Close()
+		_ = fs.file.Close()
+		fs.file = nil
+		return err
+	}
+
+	_ = fs.file.Close()
+	fs.file, fs.records, fs.size = file, len(fs.sessions), size
+
+	// The log file is replaced already (and FileStore uses it),
+	// only the rename may be lost after crash if it's failed.
+	return syncDir(filepath.Dir(fs.path))
+}
+
+// syncDir syncs the directory by path, so the files renamed in it
+// are persisted.
+func syncDir(path string) error {
+
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	err = dir.Sync()
+	if closeErr := dir.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// load replays the log file r. Returns the size of all complete records.
+// fs.mu must be locked.
+func (fs *FileStore) load(r io.Reader) (int64, error) {
+
+	br := bufio.NewReader(r)
+	var size int64
+
+	for {
+		line, err := br.ReadBytes('\n')
+		if err == io.EOF {
+			// The last record is partially written (or there is no more records).
+			return size, nil
+		}
+		if err != nil {
+			return size, err
+		}
+
+		recordSize := int64(len(line))
+
+		var rec fileRecord
+		if line = bytes.TrimSpace(line); len(line) != 0 {
+			if err := json.Unmarshal(line, &rec); err != nil {
+				return size, fmt.Errorf("session: broken record at offset %d of %s: %w", size, fs.path, err)
+			}
+			if rec.Session != nil {
+				fs.sessions[rec.Chat] = rec.Session
+			} else {
+				delete(fs.sessions, rec.Chat)
+			}
+			fs.records++
+		}
+
+		size += recordSize
+	}
+}
+
+// MakeFileStore is the FileStore constructor.
+//
+// Opens (or creates) the log file by path, replays it, discards
+// a partially written last record and applies all params
+// (see ParamCompaction, ParamSync).
+func MakeFileStore(path string, params ...interface{}) (*FileStore, error) {
+
+	fs := &FileStore{
+		path:         path,
+		sessions:     make(map[chat.IDT]*Session),
+		compactMin:   cFileStoreCompactMin,
+		compactRatio: cFileStoreCompactRatio,
+	}
+
+	for _, p := range params {
+		if p, ok := p.(fileParam); ok && p != nil {
+			p(fs)
+		}
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := fs.load(file)
+	if err == nil {
+		err = file.Truncate(size)
+	}
+	if err == nil {
+		_, err = file.Seek(0, io.SeekEnd)
+	}
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	fs.file, fs.size = file, size
+
+	// fs is not shared yet, so it's not locked.
+	if err := fs.compactIfNeeded(); err != nil {
+		if fs.file != nil {
+			_ = fs.file.Close()
+		}
+		return nil, err
+	}
+
+	return fs, nil
+}
@@ -0,0 +1,156 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package session_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/qioalice/devola/core/chat"
+	"github.com/qioalice/devola/core/session"
+	"github.com/qioalice/devola/core/session/storetest"
+)
+
+func TestFileStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) session.Store {
+		return openFileStore(t, filepath.Join(t.TempDir(), "sessions.log"))
+	})
+}
+
+func TestFileStoreCompaction(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) session.Store {
+		return openFileStore(t, filepath.Join(t.TempDir(), "sessions.log"), session.ParamCompaction(4, 2))
+	})
+}
+
+func TestFileStoreReopen(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "sessions.log")
+	fs := openFileStore(t, path)
+
+	want := map[chat.IDT]*session.Session{}
+	for i := 1; i <= 5; i++ {
+		s := &session.Session{ID: session.SessionID(i), ViewID: "view", History: session.History{"home"}}
+		mustSave(t, fs, chat.IDT(i), s)
+		want[chat.IDT(i)] = s
+	}
+
+	replaced := &session.Session{ID: 100, ViewID: "other"}
+	mustSave(t, fs, 2, replaced)
+	want[2] = replaced
+
+	if err := fs.Delete(3); err != nil {
+		t.Fatalf("Delete: unexpected error: %v", err)
+	}
+	delete(want, 3)
+
+	if err := fs.Close(); err != nil {
+		t.Fatalf("Close: unexpected error: %v", err)
+	}
+	if _, _, err := fs.Get(1); err == nil {
+		t.Fatal("Get after Close: no error")
+	}
+
+	assertSessions(t, openFileStore(t, path), want)
+}
+
+func TestFileStoreTruncatedTail(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "sessions.log")
+	fs := openFileStore(t, path)
+
+	s := &session.Session{ID: 1, ViewID: "view"}
+	mustSave(t, fs, 1, s)
+	if err := fs.Close(); err != nil {
+		t.Fatalf("Close: unexpected error: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat: unexpected error: %v", err)
+	}
+
+	// Crash in the middle of the next record.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("OpenFile: unexpected error: %v", err)
+	}
+	if _, err := f.WriteString(`{"chat":2,"session":{"id":`); err != nil {
+		t.Fatalf("WriteString: unexpected error: %v", err)
+	}
+	_ = f.Close()
+
+	fs = openFileStore(t, path)
+	assertSessions(t, fs, map[chat.IDT]*session.Session{1: s})
+
+	if info2, _ := os.Stat(path); info2.Size() != info.Size() {
+		t.Fatalf("partial record is not truncated: size %d, want %d", info2.Size(), info.Size())
+	}
+
+	// New records must be written after the last complete one.
+	s2 := &session.Session{ID: 2, ViewID: "view"}
+	mustSave(t, fs, 2, s2)
+	if err := fs.Close(); err != nil {
+		t.Fatalf("Close: unexpected error: %v", err)
+	}
+
+	assertSessions(t, openFileStore(t, path), map[chat.IDT]*session.Session{1: s, 2: s2})
+}
+
+func TestFileStoreSaveNilAfterReopen(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "sessions.log")
+	fs := openFileStore(t, path)
+
+	s := &session.Session{ID: 1, ViewID: "view"}
+	mustSave(t, fs, 1, s)
+	if err := fs.Save(1, nil); err != session.ErrNilSession {
+		t.Fatalf("Save of nil session: want ErrNilSession, have %v", err)
+	}
+	_ = fs.Close()
+
+	assertSessions(t, openFileStore(t, path), map[chat.IDT]*session.Session{1: s})
+}
+
+// openFileStore opens FileStore by path, fails t if it can't be opened
+// and closes it at the end of test (if it's not closed yet).
+func openFileStore(t *testing.T, path string, params ...interface{}) *session.FileStore {
+	t.Helper()
+	fs, err := session.MakeFileStore(path, params...)
+	if err != nil {
+		t.Fatalf("MakeFileStore: unexpected error: %v", err)
+	}
+	t.Cleanup(func() { _ = fs.Close() })
+	return fs
+}
+
+// mustSave saves s to store. Fails t if Save returns an error.
+func mustSave(t *testing.T, store session.Store, idt chat.IDT, s *session.Session) {
+	t.Helper()
+	if err := store.Save(idt, s); err != nil {
+		t.Fatalf("Save(%d): unexpected error: %v", idt, err)
+	}
+}
+
+// assertSessions fails t if store has not exactly want sessions.
+func assertSessions(t *testing.T, store session.Store, want map[chat.IDT]*session.Session) {
+	t.Helper()
+
+	have := make(map[chat.IDT]*session.Session)
+	err := store.Scan(func(idt chat.IDT, s *session.Session) bool {
+		have[idt] = s
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Scan: unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(have, want) {
+		t.Fatalf("sessions: want %+v, have %+v", want, have)
+	}
+}
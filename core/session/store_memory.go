@@ -0,0 +1,163 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package session
+
+import (
+	"sync"
+
+	"github.com/qioalice/devola/core/chat"
+)
+
+// MemoryStore is the in-memory Store.
+//
+// Sessions are distributed between shards by chat, each shard has its own
+// lock, so the sessions of different chats are rarely contended.
+type MemoryStore struct {
+	shards []memoryShard
+}
+
+// memoryShard is one shard of MemoryStore.
+type memoryShard struct {
+	mu       sync.RWMutex
+	sessions map[chat.IDT]*Session
+}
+
+// Predefined constants.
+const (
+
+	// The number of shards of MemoryStore by default.
+	cMemoryStoreShards = 32
+)
+
+// Get implements Store.
+func (ms *MemoryStore) Get(idt chat.IDT) (*Session, bool, error) {
+
+	shard := ms.shard(idt)
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	s, found := shard.sessions[idt]
+	return s.Clone(), found, nil
+}
+
+// Save implements Store.
+func (ms *MemoryStore) Save(idt chat.IDT, s *Session) error {
+
+	if s == nil {
+		return ErrNilSession
+	}
+
+	cloned := s.Clone()
+
+	shard := ms.shard(idt)
+	shard.mu.Lock()
+	shard.sessions[idt] = cloned
+	shard.mu.Unlock()
+
+	return nil
+}
+
+// SaveIf implements Store.
+func (ms *MemoryStore) SaveIf(idt chat.IDT, s *Session, f func(current *Session) bool) (bool, error) {
+
+	if s == nil {
+		return false, ErrNilSession
+	}
+
+	cloned := s.Clone()
+
+	shard := ms.shard(idt)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if f != nil && !f(shard.sessions[idt]) {
+		return false, nil
+	}
+
+	shard.sessions[idt] = cloned
+	return true, nil
+}
+
+// Delete implements Store.
+func (ms *MemoryStore) Delete(idt chat.IDT) error {
+
+	shard := ms.shard(idt)
+	shard.mu.Lock()
+	delete(shard.sessions, idt)
+	shard.mu.Unlock()
+
+	return nil
+}
+
+// DeleteIf implements Store.
+func (ms *MemoryStore) DeleteIf(idt chat.IDT, f func(current *Session) bool) (bool, error) {
+
+	shard := ms.shard(idt)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if s, found := shard.sessions[idt]; !found || f != nil && !f(s) {
+		return false, nil
+	}
+
+	delete(shard.sessions, idt)
+	return true, nil
+}
+
+// Scan implements Store.
+// Each shard is locked while its sessions are scanned.
+func (ms *MemoryStore) Scan(f func(idt chat.IDT, s *Session) (isContinue bool)) error {
+
+	for i := range ms.shards {
+		if !ms.shards[i].scan(f) {
+			break
+		}
+	}
+
+	return nil
+}
+
+// scan calls f for each session of shard until f returns false.
+// Reports whether all sessions has been scanned.
+func (shard *memoryShard) scan(f func(idt chat.IDT, s *Session) (isContinue bool)) bool {
+
+	shard.mu.RLock()
+	defer shard.mu.RUnlock()
+
+	for idt, s := range shard.sessions {
+		if !f(idt, s.Clone()) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// shard returns the shard the session of chat idt is stored in.
+func (ms *MemoryStore) shard(idt chat.IDT) *memoryShard {
+	// Fibonacci hashing spreads sequential chat IDs.
+	h := uint64(idt) * 0x9E3779B97F4A7C15
+	return &ms.shards[h%uint64(len(ms.shards))]
+}
+
+// MakeMemoryStore is the MemoryStore constructor.
+// Allocates memory for shards and applies all params (see ParamShards).
+func MakeMemoryStore(params ...interface{}) *MemoryStore {
+
+	ms := &MemoryStore{shards: make([]memoryShard, cMemoryStoreShards)}
+
+	for _, p := range params {
+		if p, ok := p.(memoryParam); ok && p != nil {
+			p(ms)
+		}
+	}
+
+	for i := range ms.shards {
+		ms.shards[i].sessions = make(map[chat.IDT]*Session)
+	}
+
+	return ms
+}
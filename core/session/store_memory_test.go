@@ -0,0 +1,25 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package session_test
+
+import (
+	"testing"
+
+	"github.com/qioalice/devola/core/session"
+	"github.com/qioalice/devola/core/session/storetest"
+)
+
+func TestMemoryStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) session.Store {
+		return session.MakeMemoryStore()
+	})
+}
+
+func TestMemoryStoreOneShard(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) session.Store {
+		return session.MakeMemoryStore(session.ParamShards(1))
+	})
+}
@@ -0,0 +1,318 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+// Package storetest is the conformance test suite of session.Store
+// implementations.
+//
+// Call Run from the test of your implementation:
+//
+//	func TestStore(t *testing.T) {
+//	    storetest.Run(t, func(t *testing.T) session.Store {
+//	        return session.MakeMemoryStore()
+//	    })
+//	}
+package storetest
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/qioalice/devola/core/chat"
+	"github.com/qioalice/devola/core/session"
+	"github.com/qioalice/devola/core/view"
+)
+
+// Run runs all conformance tests of session.Store.
+// makeStore must return a new empty Store for each test.
+func Run(t *testing.T, makeStore func(t *testing.T) session.Store) {
+
+	tests := []struct {
+		name string
+		test func(t *testing.T, store session.Store)
+	}{
+		{"GetMissing", testGetMissing},
+		{"SaveGet", testSaveGet},
+		{"SaveReplaces", testSaveReplaces},
+		{"SaveNil", testSaveNil},
+		{"SaveIf", testSaveIf},
+		{"Delete", testDelete},
+		{"DeleteIf", testDeleteIf},
+		{"Copies", testCopies},
+		{"Scan", testScan},
+		{"ScanStop", testScanStop},
+		{"Concurrent", testConcurrent},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) { tt.test(t, makeStore(t)) })
+	}
+}
+
+// makeSession returns a new session with all fields are filled.
+func makeSession(id session.SessionID) *session.Session {
+	return &session.Session{
+		ID:                  id,
+		ViewID:              "view",
+		ViewIDEncoded:       101,
+		History:             session.History{"home", "catalog"},
+		SentMessages:        chat.MessageIDs{1, 2, 3},
+		ExpirationUnixstamp: 1600000000,
+		Data:                session.Data{"page": []byte("2"), "query": []byte(`"shoes"`)},
+	}
+}
+
+// mustGet returns the session of idt from store and reports whether
+// it is found. Fails t if Get returns an error.
+func mustGet(t *testing.T, store session.Store, idt chat.IDT) (*session.Session, bool) {
+	t.Helper()
+	s, found, err := store.Get(idt)
+	if err != nil {
+		t.Fatalf("Get(%d): unexpected error: %v", idt, err)
+	}
+	return s, found
+}
+
+// mustSave saves s to store. Fails t if Save returns an error.
+func mustSave(t *testing.T, store session.Store, idt chat.IDT, s *session.Session) {
+	t.Helper()
+	if err := store.Save(idt, s); err != nil {
+		t.Fatalf("Save(%d): unexpected error: %v", idt, err)
+	}
+}
+
+func testGetMissing(t *testing.T, store session.Store) {
+	if s, found := mustGet(t, store, 1); found || s != nil {
+		t.Fatalf("Get of missing session: want nil, false, have %+v, %v", s, found)
+	}
+}
+
+func testSaveGet(t *testing.T, store session.Store) {
+
+	idt := chat.NewIDT(-100500, 3)
+	want := makeSession(7)
+	mustSave(t, store, idt, want)
+
+	have, found := mustGet(t, store, idt)
+	if !found || !reflect.DeepEqual(have, want) {
+		t.Fatalf("Get after Save: want %+v, have %+v (found: %v)", want, have, found)
+	}
+}
+
+func testSaveReplaces(t *testing.T, store session.Store) {
+
+	mustSave(t, store, 1, makeSession(1))
+	want := makeSession(2)
+	want.ViewID = view.ID("other")
+	mustSave(t, store, 1, want)
+
+	if have, _ := mustGet(t, store, 1); !reflect.DeepEqual(have, want) {
+		t.Fatalf("Get after second Save: want %+v, have %+v", want, have)
+	}
+}
+
+func testSaveNil(t *testing.T, store session.Store) {
+
+	mustSave(t, store, 1, makeSession(1))
+
+	if err := store.Save(1, nil); !errors.Is(err, session.ErrNilSession) {
+		t.Fatalf("Save of nil session: want ErrNilSession, have %v", err)
+	}
+	if _, found := mustGet(t, store, 1); !found {
+		t.Fatal("Save of nil session removed the saved one")
+	}
+}
+
+func testSaveIf(t *testing.T, store session.Store) {
+
+	isMissing := func(current *session.Session) bool { return current == nil }
+	isFirst := func(current *session.Session) bool { return current != nil && current.ID == 1 }
+
+	mustSaveIf := func(s *session.Session, f func(*session.Session) bool, want bool) {
+		t.Helper()
+		saved, err := store.SaveIf(1, s, f)
+		if err != nil {
+			t.Fatalf("SaveIf: unexpected error: %v", err)
+		}
+		if saved != want {
+			t.Fatalf("SaveIf: want saved %v, have %v", want, saved)
+		}
+	}
+
+	mustSaveIf(makeSession(2), isFirst, false)
+	if _, found := mustGet(t, store, 1); found {
+		t.Fatal("SaveIf with false condition saved the session")
+	}
+
+	mustSaveIf(makeSession(1), isMissing, true)
+	mustSaveIf(makeSession(3), isMissing, false)
+	mustSaveIf(makeSession(2), isFirst, true)
+
+	if have, _ := mustGet(t, store, 1); !reflect.DeepEqual(have, makeSession(2)) {
+		t.Fatalf("Get after SaveIf: want %+v, have %+v", makeSession(2), have)
+	}
+
+	if _, err := store.SaveIf(1, nil, nil); !errors.Is(err, session.ErrNilSession) {
+		t.Fatalf("SaveIf of nil session: want ErrNilSession, have %v", err)
+	}
+}
+
+func testDeleteIf(t *testing.T, store session.Store) {
+
+	mustSave(t, store, 1, makeSession(1))
+
+	isSecond := func(current *session.Session) bool { return current.ID == 2 }
+	isFirst := func(current *session.Session) bool { return current.ID == 1 }
+
+	for _, tt := range []struct {
+		idt  chat.IDT
+		f    func(*session.Session) bool
+		want bool
+	}{
+		{2, isFirst, false}, // missing session, f must not be called with nil
+		{1, isSecond, false},
+		{1, isFirst, true},
+		{1, isFirst, false},
+	} {
+		deleted, err := store.DeleteIf(tt.idt, tt.f)
+		if err != nil {
+			t.Fatalf("DeleteIf(%d): unexpected error: %v", tt.idt, err)
+		}
+		if deleted != tt.want {
+			t.Fatalf("DeleteIf(%d): want deleted %v, have %v", tt.idt, tt.want, deleted)
+		}
+	}
+
+	if _, found := mustGet(t, store, 1); found {
+		t.Fatal("Get after DeleteIf: session is found")
+	}
+}
+
+func testDelete(t *testing.T, store session.Store) {
+
+	mustSave(t, store, 1, makeSession(1))
+	mustSave(t, store, 2, makeSession(2))
+
+	if err := store.Delete(1); err != nil {
+		t.Fatalf("Delete: unexpected error: %v", err)
+	}
+	if err := store.Delete(3); err != nil {
+		t.Fatalf("Delete of missing session: unexpected error: %v", err)
+	}
+
+	if _, found := mustGet(t, store, 1); found {
+		t.Fatal("Get after Delete: session is found")
+	}
+	if _, found := mustGet(t, store, 2); !found {
+		t.Fatal("Delete removed another session")
+	}
+}
+
+func testCopies(t *testing.T, store session.Store) {
+
+	s := makeSession(1)
+	mustSave(t, store, 1, s)
+
+	s.ViewID = "changed"
+	s.History[0] = "changed"
+	s.SentMessages[0] = 100
+	s.Data["page"][0] = '9'
+
+	have, _ := mustGet(t, store, 1)
+	if !reflect.DeepEqual(have, makeSession(1)) {
+		t.Fatalf("Store keeps saved object: have %+v", have)
+	}
+
+	have.History[1] = "changed"
+	if again, _ := mustGet(t, store, 1); !reflect.DeepEqual(again, makeSession(1)) {
+		t.Fatalf("Store returns kept object: have %+v", again)
+	}
+}
+
+func testScan(t *testing.T, store session.Store) {
+
+	want := make(map[chat.IDT]session.SessionID)
+	for i := 1; i <= 100; i++ {
+		want[chat.IDT(i)] = session.SessionID(i * 10)
+		mustSave(t, store, chat.IDT(i), makeSession(session.SessionID(i*10)))
+	}
+	if err := store.Delete(50); err != nil {
+		t.Fatalf("Delete: unexpected error: %v", err)
+	}
+	delete(want, 50)
+
+	have := make(map[chat.IDT]session.SessionID)
+	err := store.Scan(func(idt chat.IDT, s *session.Session) bool {
+		if _, found := have[idt]; found {
+			t.Errorf("Scan: session of %d is scanned twice", idt)
+		}
+		have[idt] = s.ID
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Scan: unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(have, want) {
+		t.Fatalf("Scan: want %v, have %v", want, have)
+	}
+}
+
+func testScanStop(t *testing.T, store session.Store) {
+
+	for i := 1; i <= 10; i++ {
+		mustSave(t, store, chat.IDT(i), makeSession(1))
+	}
+
+	calls := 0
+	err := store.Scan(func(chat.IDT, *session.Session) bool {
+		calls++
+		return calls < 3
+	})
+	if err != nil {
+		t.Fatalf("Scan: unexpected error: %v", err)
+	}
+
+	if calls != 3 {
+		t.Fatalf("Scan is not stopped: %d calls, want 3", calls)
+	}
+}
+
+func testConcurrent(t *testing.T, store session.Store) {
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				idt := chat.IDT(g*1000 + i)
+				if err := store.Save(idt, makeSession(session.SessionID(i+1))); err != nil {
+					t.Errorf("Save: unexpected error: %v", err)
+					return
+				}
+				if _, _, err := store.Get(idt); err != nil {
+					t.Errorf("Get: unexpected error: %v", err)
+					return
+				}
+				if i%2 == 0 {
+					if err := store.Delete(idt); err != nil {
+						t.Errorf("Delete: unexpected error: %v", err)
+						return
+					}
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	count := 0
+	_ = store.Scan(func(chat.IDT, *session.Session) bool { count++; return true })
+	if count != 8*50 {
+		t.Fatalf("after concurrent use: %d sessions, want %d", count, 8*50)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package sweeper
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/qioalice/devola/core/registrator"
+)
+
+// param is an alias to function that takes a Sweeper object and changes
+// its behaviour.
+// It uses as parameters for Sweeper constructor.
+type param func(sw *Sweeper)
+
+// ParamInterval sets how often Sweeper sweeps (1 minute by default).
+// Non-positive values are ignored.
+func ParamInterval(interval time.Duration) param {
+	return func(sw *Sweeper) {
+		if interval > 0 {
+			sw.interval = interval
+		}
+	}
+}
+
+// ParamRegistrator sets the Registrator expired sessions are dispatched to
+// (see Sweeper) and newCtx that returns a new context object of the type
+// r is created for (backends provide it as bridge.Bridge's CtxNext).
+// Ignored if r or newCtx is nil (expired sessions are just deleted or reset then).
+func ParamRegistrator(r *registrator.Registrator, newCtx func() unsafe.Pointer) param {
+	return func(sw *Sweeper) {
+		if r != nil && newCtx != nil {
+			sw.registrator, sw.newCtx = r, newCtx
+		}
+	}
+}
+
+// ParamBatch sets how many expired sessions are handled between checks
+// whether Sweeper is stopped (256 by default). Non-positive values are ignored.
+func ParamBatch(size int) param {
+	return func(sw *Sweeper) {
+		if size > 0 {
+			sw.batch = size
+		}
+	}
+}
+
+// ParamEternal sets how eternal sessions are handled
+// (EternalKeep by default, see EternalPolicy).
+// EternalExpire can't be used with ActionReset (see MakeSweeper).
+func ParamEternal(policy EternalPolicy) param {
+	return func(sw *Sweeper) { sw.eternal = policy }
+}
+
+// ParamAction sets what is done with expired sessions after handlers
+// (ActionDelete by default, see Action).
+func ParamAction(action Action) param {
+	return func(sw *Sweeper) { sw.action = action }
+}
+
+// ParamOnError sets the hook that is called when the sweep started
+// by Sweeper.Start is failed (by store error).
+func ParamOnError(hook func(err error)) param {
+	return func(sw *Sweeper) { sw.onError = hook }
+}
@@ -0,0 +1,326 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package sweeper
+
+import (
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/qioalice/devola/core/chat"
+	"github.com/qioalice/devola/core/ctx"
+	"github.com/qioalice/devola/core/event"
+	"github.com/qioalice/devola/core/registrator"
+	"github.com/qioalice/devola/core/session"
+)
+
+// Sweeper is the background finder of expired sessions of session.Store.
+//
+// Each expired session is dispatched to the Registrator (see ParamRegistrator)
+// as a synthetic event of type event.CTypeExpired in the context
+// with the expired session and its chat. So its handlers are registered
+// as for any other event and get the context of user's type:
+//
+//	r.Simple(event.CTypeExpired, []string{"catalog"}).Handler(func(c *MyCtx) {
+//		// edit the last sent message (see session.Session.SentMessages)
+//		// to "this menu has expired"
+//	})
+//
+// Like any other event, it's passed to fallback or main handlers
+// if there is no handlers registered for it.
+// Then the session is deleted or reset (see ParamAction).
+//
+// If some handler extends the session (it's not expired anymore after handlers),
+// the changed session is saved instead.
+//
+// The session is deleted, reset or saved only if it's not changed in the store
+// since it has been found expired (its ID and expiration time are the same),
+// so the session that has been extended meanwhile is never lost.
+type Sweeper struct {
+	store session.Store
+
+	// Expired sessions are dispatched to registrator in context objects
+	// made by newCtx (see ParamRegistrator). Nil registrator if there is none.
+	registrator *registrator.Registrator
+	newCtx      func() unsafe.Pointer
+
+	// Protects chStop.
+	mu sync.Mutex
+
+	// Serializes sweeps, so the sweep started by Sweep method and the sweep
+	// of background goroutine never handle the same session twice.
+	muSweep sync.Mutex
+
+	// Configuration (see params).
+	interval time.Duration
+	batch    int
+	eternal  EternalPolicy
+	action   Action
+	onError  func(err error)
+
+	// Closed by Stop. Nil if Sweeper is not started.
+	chStop chan struct{}
+	wg     sync.WaitGroup
+}
+
+// EternalPolicy represents how Sweeper handles eternal sessions
+// (see session.CExpirationEternal).
+type EternalPolicy uint8
+
+// Predefined eternal policies.
+const (
+
+	// Eternal sessions are never expired (by default).
+	EternalKeep EternalPolicy = iota
+
+	// Eternal sessions are handled as expired ones (they are dispatched),
+	// e.g. to clean up them once.
+	EternalExpire
+
+	// Eternal sessions are deleted without dispatching.
+	EternalDelete
+)
+
+// Action represents what Sweeper does with expired session after handlers.
+type Action uint8
+
+// Predefined actions.
+const (
+
+	// Expired session is deleted from the store (by default).
+	ActionDelete Action = iota
+
+	// Expired session is reset: its views, history and sent messages
+	// are cleared and it becomes eternal (keeping its ID).
+	ActionReset
+)
+
+// Predefined constants.
+const (
+
+	// Default configuration of Sweeper (see params).
+	cSweeperInterval = time.Minute
+	cSweeperBatch    = 256
+)
+
+// Start starts sweeping in the separated goroutine each interval
+// (see ParamInterval). Does nothing if sw is started already.
+func (sw *Sweeper) Start() {
+
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	if sw.chStop != nil {
+		return
+	}
+
+	sw.chStop = make(chan struct{})
+	sw.wg.Add(1)
+	go sw.run(sw.chStop)
+}
+
+// Stop stops sweeping started by Start and waits for the current sweep
+// is done. Does nothing if sw is not started.
+func (sw *Sweeper) Stop() {
+
+	sw.mu.Lock()
+	chStop := sw.chStop
+	sw.chStop = nil
+	sw.mu.Unlock()
+
+	if chStop != nil {
+		close(chStop)
+		sw.wg.Wait()
+	}
+}
+
+// Sweep handles all sessions that are expired now and returns
+// how many of them has been handled.
+//
+// Expired sessions are collected by one pass over the store and then
+// handled by batches (see ParamBatch). Each session is reloaded before
+// it's handled, so sessions that are extended or deleted since they has been
+// collected are skipped (and not counted). Sessions that are changed
+// in the store while they are being handled are not deleted or reset
+// (but counted).
+//
+// Sweeps are serialized: Sweep waits for the current sweep
+// (of background goroutine or another Sweep call) is done.
+func (sw *Sweeper) Sweep() (int, error) {
+	return sw.sweep(nil)
+}
+
+// sweep is the Sweep's core.
+// The sweep is interrupted between batches when chStop is closed.
+func (sw *Sweeper) sweep(chStop <-chan struct{}) (int, error) {
+
+	sw.muSweep.Lock()
+	defer sw.muSweep.Unlock()
+
+	now := time.Now().Unix()
+
+	idts, err := sw.collect(now)
+	if err != nil {
+		return 0, err
+	}
+
+	handled := 0
+	for len(idts) != 0 {
+
+		batch := idts
+		if len(batch) > sw.batch {
+			batch = batch[:sw.batch]
+		}
+		idts = idts[len(batch):]
+
+		for _, idt := range batch {
+			s, found, err := sw.store.Get(idt)
+			if err != nil {
+				return handled, err
+			}
+			if !found || !sw.isExpiredAt(s, now) {
+				continue
+			}
+			if err := sw.handle(idt, s, now); err != nil {
+				return handled, err
+			}
+			handled++
+		}
+
+		select {
+		case <-chStop:
+			return handled, nil
+		default:
+		}
+	}
+
+	return handled, nil
+}
+
+// run is the goroutine of Sweeper.
+func (sw *Sweeper) run(chStop chan struct{}) {
+
+	defer sw.wg.Done()
+
+	ticker := time.NewTicker(sw.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-chStop:
+			return
+		case <-ticker.C:
+			if _, err := sw.sweep(chStop); err != nil && sw.onError != nil {
+				sw.onError(err)
+			}
+		}
+	}
+}
+
+// collect returns the chats of all sessions that are expired at now.
+func (sw *Sweeper) collect(now int64) ([]chat.IDT, error) {
+
+	var idts []chat.IDT
+	err := sw.store.Scan(func(idt chat.IDT, s *session.Session) bool {
+		if sw.isExpiredAt(s, now) {
+			idts = append(idts, idt)
+		}
+		return true
+	})
+
+	return idts, err
+}
+
+// isExpiredAt reports whether s must be handled as expired at now.
+func (sw *Sweeper) isExpiredAt(s *session.Session, now int64) bool {
+	if s.IsEternal() {
+		return sw.eternal != EternalKeep
+	}
+	return s.IsExpiredAt(now)
+}
+
+// handle dispatches expired session s of chat idt to the registrator
+// and then deletes, resets or saves it if it's not changed in the store
+// since it has been loaded (see isUnchanged).
+func (sw *Sweeper) handle(idt chat.IDT, s *session.Session, now int64) error {
+
+	isUnchanged := isUnchanged(s)
+
+	if s.IsEternal() && sw.eternal == EternalDelete {
+		_, err := sw.store.DeleteIf(idt, isUnchanged)
+		return err
+	}
+
+	c := new(ctx.BaseCtx)
+	if sw.registrator != nil {
+		c = (*ctx.BaseCtx)(sw.newCtx())
+	}
+
+	c.Event = event.Event{Type: event.CTypeExpired}
+	c.Session, c.Chat, c.SenderRole = *s, idt, chat.CRoleUnknown
+
+	if sw.registrator != nil {
+		sw.registrator.Dispatch(unsafe.Pointer(c), nil)
+	}
+
+	var err error
+	switch {
+
+	// The session is extended by handler.
+	case !sw.isExpiredAt(&c.Session, now):
+		_, err = sw.store.SaveIf(idt, &c.Session, isUnchanged)
+
+	case sw.action == ActionReset:
+		_, err = sw.store.SaveIf(idt, &session.Session{
+			ID:                  c.Session.ID,
+			ExpirationUnixstamp: session.CExpirationEternal,
+		}, isUnchanged)
+
+	default:
+		_, err = sw.store.DeleteIf(idt, isUnchanged)
+	}
+
+	return err
+}
+
+// isUnchanged returns a condition of session.Store's SaveIf and DeleteIf:
+// the session in the store is the same session as s and it's not extended
+// (so it's still expired as s is).
+func isUnchanged(s *session.Session) func(current *session.Session) bool {
+	return func(current *session.Session) bool {
+		return current != nil && current.ID == s.ID &&
+			current.ExpirationUnixstamp == s.ExpirationUnixstamp
+	}
+}
+
+// MakeSweeper is the Sweeper constructor.
+//
+// Saves store, expired sessions are found in, and applies all params
+// (see ParamRegistrator, ParamInterval, ParamBatch, ParamEternal,
+// ParamAction, ParamOnError). Sweeper is not started (see Start).
+//
+// Panics if EternalExpire is used with ActionReset: reset sessions
+// are eternal, so they would be expired and reset again at each sweep.
+func MakeSweeper(store session.Store, params ...interface{}) *Sweeper {
+
+	sw := &Sweeper{
+		store:    store,
+		interval: cSweeperInterval,
+		batch:    cSweeperBatch,
+	}
+
+	for _, p := range params {
+		if p, ok := p.(param); ok && p != nil {
+			p(sw)
+		}
+	}
+
+	if sw.eternal == EternalExpire && sw.action == ActionReset {
+		panic("sweeper: EternalExpire can't be used with ActionReset, reset sessions are eternal")
+	}
+
+	return sw
+}
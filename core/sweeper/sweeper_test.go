@@ -0,0 +1,208 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package sweeper
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/qioalice/devola/core/chat"
+	"github.com/qioalice/devola/core/ctx"
+	"github.com/qioalice/devola/core/event"
+	"github.com/qioalice/devola/core/registrator"
+	"github.com/qioalice/devola/core/session"
+	"github.com/qioalice/devola/core/view"
+)
+
+// testCtx is the user's context type expired sessions are dispatched in.
+type testCtx struct {
+	ctx.BaseCtx
+	isTest bool
+}
+
+// makeTestSweeper returns Sweeper of store which expired sessions
+// are dispatched to handler registered for views when (any view if it's nil).
+func makeTestSweeper(t *testing.T, store session.Store, idc *view.IDConv, when []string, handler func(c *testCtx), params ...interface{}) *Sweeper {
+	t.Helper()
+
+	isSimple := func(event.Type) bool { return false }
+	r := registrator.MakeRegistrator(idc, reflect.TypeOf((*testCtx)(nil)), isSimple)
+
+	if err := r.Simple(event.CTypeExpired, when).Handler(func(c *testCtx) {
+		if !c.isTest {
+			t.Errorf("handler is called with context not made by newCtx")
+		}
+		handler(c)
+	}); err != nil {
+		t.Fatalf("Handler: unexpected error: %v", err)
+	}
+
+	newCtx := func() unsafe.Pointer { return unsafe.Pointer(&testCtx{isTest: true}) }
+	return MakeSweeper(store, append(params, ParamRegistrator(r, newCtx))...)
+}
+
+// expiredSession returns a new session with id that is expired a minute ago.
+func expiredSession(id session.SessionID) *session.Session {
+	return &session.Session{ID: id, ExpirationUnixstamp: time.Now().Add(-time.Minute).Unix()}
+}
+
+func TestSweepDeletesExpired(t *testing.T) {
+
+	store := session.MakeMemoryStore()
+	alive := &session.Session{ID: 2, ExpirationUnixstamp: time.Now().Add(time.Hour).Unix()}
+
+	mustSave(t, store, 1, expiredSession(1))
+	mustSave(t, store, 2, alive)
+
+	var hooked []chat.IDT
+	sw := makeTestSweeper(t, store, view.MakeIDConv(), nil, func(c *testCtx) {
+		if c.Event.Type != event.CTypeExpired || c.Session.ID != 1 {
+			t.Errorf("handler is called with event %v of session %d", c.Event.Type, c.Session.ID)
+		}
+		hooked = append(hooked, c.Chat)
+	})
+
+	if n, err := sw.Sweep(); n != 1 || err != nil {
+		t.Fatalf("Sweep: got %d, %v, want 1, nil", n, err)
+	}
+	if len(hooked) != 1 || hooked[0] != 1 {
+		t.Fatalf("hooks are called for %v, want [1]", hooked)
+	}
+
+	if _, found, _ := store.Get(1); found {
+		t.Fatal("expired session is not deleted")
+	}
+	if _, found, _ := store.Get(2); !found {
+		t.Fatal("alive session is deleted")
+	}
+}
+
+func TestSweepSkipsExtendedMeanwhile(t *testing.T) {
+
+	for _, action := range []Action{ActionDelete, ActionReset} {
+
+		store := session.MakeMemoryStore()
+		mustSave(t, store, 1, expiredSession(1))
+
+		// The user extends the session while hooks are called.
+		extended := &session.Session{ID: 1, ExpirationUnixstamp: time.Now().Add(time.Hour).Unix()}
+
+		sw := makeTestSweeper(t, store, view.MakeIDConv(), nil, func(c *testCtx) {
+			mustSave(t, store, c.Chat, extended)
+		}, ParamAction(action))
+
+		if _, err := sw.Sweep(); err != nil {
+			t.Fatalf("Sweep: unexpected error: %v", err)
+		}
+
+		s, found, _ := store.Get(1)
+		if !found || s.ExpirationUnixstamp != extended.ExpirationUnixstamp {
+			t.Fatalf("action %d: session extended meanwhile is lost: %+v, found %v", action, s, found)
+		}
+	}
+}
+
+func TestSweepIsSerialized(t *testing.T) {
+
+	const sessions = 200
+
+	store := session.MakeMemoryStore()
+	for i := 1; i <= sessions; i++ {
+		mustSave(t, store, chat.IDT(i), expiredSession(session.SessionID(i)))
+	}
+
+	// Slow handlers make the sweeps overlap.
+	var calls int64
+	sw := makeTestSweeper(t, store, view.MakeIDConv(), nil, func(*testCtx) {
+		atomic.AddInt64(&calls, 1)
+		time.Sleep(100 * time.Microsecond)
+	}, ParamBatch(16))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := sw.Sweep(); err != nil {
+				t.Errorf("Sweep: unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls != sessions {
+		t.Fatalf("hooks are called %d times, want %d", calls, sessions)
+	}
+}
+
+func TestSweepRoutesByView(t *testing.T) {
+
+	idc := view.MakeIDConv()
+	_ = idc.Register("catalog", "cart")
+
+	store := session.MakeMemoryStore()
+	for i, id := range []view.ID{"catalog", "cart"} {
+		s := expiredSession(session.SessionID(i + 1))
+		s.ViewID, s.ViewIDEncoded = id, idc.Encode(id)
+		mustSave(t, store, chat.IDT(i+1), s)
+	}
+
+	var hooked []chat.IDT
+	sw := makeTestSweeper(t, store, idc, []string{"catalog"}, func(c *testCtx) {
+		hooked = append(hooked, c.Chat)
+	})
+
+	if n, err := sw.Sweep(); n != 2 || err != nil {
+		t.Fatalf("Sweep: got %d, %v, want 2, nil", n, err)
+	}
+	if len(hooked) != 1 || hooked[0] != 1 {
+		t.Fatalf("handler is called for %v, want [1]", hooked)
+	}
+}
+
+func TestSweepResetsOnce(t *testing.T) {
+
+	store := session.MakeMemoryStore()
+	mustSave(t, store, 1, expiredSession(1))
+
+	var calls int
+	sw := makeTestSweeper(t, store, view.MakeIDConv(), nil, func(*testCtx) { calls++ },
+		ParamAction(ActionReset))
+
+	for i := 0; i < 2; i++ {
+		if _, err := sw.Sweep(); err != nil {
+			t.Fatalf("Sweep: unexpected error: %v", err)
+		}
+	}
+
+	s, found, _ := store.Get(1)
+	if !found || !s.IsEternal() || s.ID != 1 || calls != 1 {
+		t.Fatalf("reset session: %+v, found %v, handled %d times, want eternal, once", s, found, calls)
+	}
+}
+
+func TestMakeSweeperEternalExpireReset(t *testing.T) {
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("MakeSweeper with EternalExpire and ActionReset: no panic")
+		}
+	}()
+
+	MakeSweeper(session.MakeMemoryStore(), ParamEternal(EternalExpire), ParamAction(ActionReset))
+}
+
+// mustSave saves s to store. Fails t if Save returns an error.
+func mustSave(t *testing.T, store session.Store, idt chat.IDT, s *session.Session) {
+	t.Helper()
+	if err := store.Save(idt, s); err != nil {
+		t.Fatalf("Save(%d): unexpected error: %v", idt, err)
+	}
+}
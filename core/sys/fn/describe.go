@@ -0,0 +1,56 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package fn
+
+import (
+	"reflect"
+	"runtime"
+	"unsafe"
+)
+
+// Describe returns a full name of function fn and the source location
+// (file, line) where it is declared.
+//
+// Returns empty name, empty file and 0 as line if fn is nil or not a function.
+// For func literals the name is generated by Golang (like "pkg.f.func1").
+func Describe(fn interface{}) (name, file string, line int) {
+
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return "", "", 0
+	}
+
+	return describe(v.Pointer())
+}
+
+// DescribeCallable is the same as Describe but takes a callable address
+// of function (see TakeCallableAddr).
+//
+// Returns empty name, empty file and 0 as line if callablePtr is nil.
+func DescribeCallable(callablePtr unsafe.Pointer) (name, file string, line int) {
+
+	normalPtr := AddrConvert2Normal(callablePtr)
+	if normalPtr == nil {
+		return "", "", 0
+	}
+
+	// The real address of function points to the closure object,
+	// which first word is the address of function's code.
+	return describe(*(*uintptr)(normalPtr))
+}
+
+// describe is the Describe and DescribeCallable's core.
+// pc is the address of function's code.
+func describe(pc uintptr) (name, file string, line int) {
+
+	f := runtime.FuncForPC(pc)
+	if f == nil {
+		return "", "", 0
+	}
+
+	file, line = f.FileLine(f.Entry())
+	return f.Name(), file, line
+}
@@ -6,6 +6,7 @@
 package fn
 
 import (
+	"reflect"
 	"unsafe"
 )
 
@@ -17,6 +18,11 @@ import (
 type Named struct {
 	Name string
 	Ptr  unsafe.Pointer
+
+	// The type of function.
+	// Nil if Named object has been created using callable pointer
+	// (the type can not be figured out by pointer).
+	Type reflect.Type
 }
 
 // MakeNamed creates a Named object using passed name and function object.
@@ -32,11 +38,13 @@ type Named struct {
 func MakeNamed(name string, fn interface{}) Named {
 
 	var ptr unsafe.Pointer
+	var typ reflect.Type
 
 	// using a second argument disables panic if fn is not unsafe.Pointer
 	if ptr, _ = fn.(unsafe.Pointer); ptr == nil {
 		ptr = TakeCallableAddr(fn)
+		typ = reflect.TypeOf(fn)
 	}
 
-	return Named{name, ptr}
+	return Named{name, ptr, typ}
 }
@@ -0,0 +1,37 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package fn
+
+// Registry is a set of Named objects that can be found by their names.
+//
+// It allows to refer to the functions by their names from the places
+// where Go code can not be used (config files, manifests, etc).
+// Registry is not thread-safe. Fill it before using.
+type Registry map[string]Named
+
+// MakeRegistry creates a new Registry object and adds all named to it.
+func MakeRegistry(named ...Named) Registry {
+	return make(Registry, len(named)).Add(named...)
+}
+
+// Add adds all named to r. Overwrites the Named objects with the same names.
+// Named objects with empty names or nil pointers are ignored.
+// Returns r.
+func (r Registry) Add(named ...Named) Registry {
+	for _, n := range named {
+		if n.Name != "" && n.Ptr != nil {
+			r[n.Name] = n
+		}
+	}
+	return r
+}
+
+// Get returns the Named object with passed name and true
+// or an empty Named object and false if there is no such object in r.
+func (r Registry) Get(name string) (Named, bool) {
+	n, found := r[name]
+	return n, found
+}
@@ -0,0 +1,141 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package view
+
+import (
+	"github.com/qioalice/devola/core/errors"
+)
+
+// Alias declares that View ID old is renamed to View ID new.
+//
+// After that, old (and its encoded View ID, if old has been registered,
+// e.g. by Import of persisted Mapping) is resolved to new:
+// Encode of old returns an encoded new, Decode of an encoded old returns new.
+// So sessions and inline buttons with old View ID continue to work.
+// new is registered if it is not (regardless of auto registration).
+//
+// Returns ECInvalidID if old or new is invalid or they are the same,
+// ECAliasConflict if old is already an alias of another View ID
+// or new is an alias of old (directly or not),
+// or ECInvalidIDEnc if there is no free encoded View IDs anymore.
+func (idc *IDConv) Alias(old, new ID) errors.Code {
+
+	if !old.IsValid() || !new.IsValid() || old == new {
+		return ECInvalidID
+	}
+
+	if idenc, _ := idc.encode(new); idenc == CIDEncNil {
+		return ECInvalidIDEnc
+	}
+
+	idc.mu.Lock()
+	defer idc.mu.Unlock()
+
+	if target, found := idc.mAliases[old]; found {
+		if target == new {
+			return errors.ECOK
+		}
+		return ECAliasConflict
+	}
+
+	if idc.resolve(new) == old {
+		return ECAliasConflict
+	}
+
+	if idc.mAliases == nil {
+		idc.mAliases = make(map[ID]ID)
+	}
+
+	idc.mAliases[old] = new
+	return errors.ECOK
+}
+
+// Unalias removes the alias old declared by Alias.
+// Do it when there is no sessions pointing to old anymore
+// (see Deprecated and session.DeprecatedSessions).
+// Reports whether there was such alias.
+//
+// The encoded View ID of old (if any) stays registered for old,
+// so it won't be given to another View ID.
+func (idc *IDConv) Unalias(old ID) bool {
+
+	idc.mu.Lock()
+	defer idc.mu.Unlock()
+
+	_, found := idc.mAliases[old]
+	delete(idc.mAliases, old)
+	return found
+}
+
+// Aliases returns all declared aliases as links old View ID -> new View ID.
+func (idc *IDConv) Aliases() map[ID]ID {
+
+	idc.mu.RLock()
+	defer idc.mu.RUnlock()
+
+	aliases := make(map[ID]ID, len(idc.mAliases))
+	for old, new := range idc.mAliases {
+		aliases[old] = new
+	}
+	return aliases
+}
+
+// Resolve returns the View ID id is renamed to (following all aliases),
+// or id itself if it is not an alias.
+func (idc *IDConv) Resolve(id ID) ID {
+	idc.mu.RLock()
+	defer idc.mu.RUnlock()
+	return idc.resolve(id)
+}
+
+// Canonical returns the encoded View ID of the View ID idenc is resolved to
+// (see Resolve), or idenc itself if it is not an encoded alias.
+func (idc *IDConv) Canonical(idenc IDEnc) IDEnc {
+
+	idc.mu.RLock()
+	defer idc.mu.RUnlock()
+
+	if id, found := idc.mDecodeStorage[idenc]; found && idc.isAlias(id) {
+		return idc.mEncodeStorage[idc.resolve(id)]
+	}
+	return idenc
+}
+
+// Deprecated reports whether id or View ID encoded idenc is an alias
+// (see Alias), and returns that deprecated View ID.
+// Pass CIDNil or CIDEncNil to check only one of them.
+func (idc *IDConv) Deprecated(id ID, idenc IDEnc) (ID, bool) {
+
+	idc.mu.RLock()
+	defer idc.mu.RUnlock()
+
+	if idc.isAlias(id) {
+		return id, true
+	}
+	if id, found := idc.mDecodeStorage[idenc]; found && idc.isAlias(id) {
+		return id, true
+	}
+	return CIDNil, false
+}
+
+// isAlias reports whether id is an alias of another View ID.
+// idc.mu must be locked.
+func (idc *IDConv) isAlias(id ID) bool {
+	_, found := idc.mAliases[id]
+	return found
+}
+
+// resolve is the same as Resolve but idc.mu must be locked.
+// Aliases can't make a cycle (see Alias).
+func (idc *IDConv) resolve(id ID) ID {
+	for {
+		target, found := idc.mAliases[id]
+		if !found {
+			return id
+		}
+		id = target
+	}
+}
@@ -10,7 +10,7 @@ import (
 )
 
 // Predefined error codes of all convert operations.
-// These codes may be returned from IDConv methods.
+// These codes may be returned from IDConv and Graph methods.
 const (
 
 	// Invalid View ID error.
@@ -35,4 +35,40 @@ const (
 	//   pair (as a conclusion, there is no registered View ID with received
 	//   encoded View ID).
 	ECNotRegistered errors.Code = 3
+
+	// Mapping conflict error.
+	// Returned:
+	// - From Import method if some View ID of imported Mapping is already
+	//   registered with another encoded View ID or some encoded View ID
+	//   is already taken by another View ID.
+	ECMappingConflict errors.Code = 4
+
+	// Mapping changed error.
+	// Returned:
+	// - From Verify method if some View ID of persisted Mapping is registered
+	//   with another encoded View ID now.
+	ECMappingChanged errors.Code = 5
+
+	// Alias conflict error.
+	// Returned:
+	// - From Alias method if old View ID is already an alias of another
+	//   View ID or new View ID is an alias of old View ID (directly or not).
+	ECAliasConflict errors.Code = 6
+
+	// Unknown view error.
+	// Returned:
+	// - From Graph.Check method if the view of transition is not declared.
+	ECUnknownView errors.Code = 7
+
+	// Illegal transition error.
+	// Returned:
+	// - From Graph.Check method if there is no declared transition
+	//   between views for the event.
+	ECIllegalTransition errors.Code = 8
+
+	// Bad View ID parameters error.
+	// Returned:
+	// - From DecodeParams method if packed parameters don't fit
+	//   the View ID template.
+	ECBadParams errors.Code = 9
 )
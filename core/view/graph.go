@@ -0,0 +1,262 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package view
+
+import (
+	"sync"
+
+	"github.com/qioalice/devola/core/errors"
+	"github.com/qioalice/devola/core/event"
+)
+
+// Graph is the declared state machine of views: what views there are,
+// what transitions between them are allowed and what events trigger them.
+//
+// Use it to check the transitions of sessions at runtime (see Check)
+// and views of routes at startup (see registrator.Registrator.CheckGraph).
+//
+// Graph is safe for concurrent use.
+type Graph struct {
+
+	// Protects all fields below (params are set once by constructor).
+	mu sync.RWMutex
+
+	// All declared views in the order they has been declared.
+	views []ID
+
+	// Declared views (as a set).
+	mViews map[ID]struct{}
+
+	// Views a session without view can be transited to (see Entry).
+	mEntries map[ID]struct{}
+
+	// Allowed transitions from each view in the order they has been declared.
+	mTransitions map[ID][]Transition
+
+	// Reject illegal transitions (see ParamGraphStrict).
+	isStrict bool
+
+	// Called when illegal transition is checked (see ParamOnIllegalTransition).
+	onIllegal func(from, to ID, e event.Event, code errors.Code)
+}
+
+// Transition is one allowed transition between views.
+//
+// Type is event.CTypeInvalid if transition can be triggered by any event.
+// Data is event.CDataNil if transition can be triggered by event of Type
+// with any data.
+type Transition struct {
+	From ID         `json:"from"`
+	To   ID         `json:"to"`
+	Type event.Type `json:"type,omitempty"`
+	Data event.Data `json:"data,omitempty"`
+}
+
+// View declares all passed views. Invalid View IDs are ignored.
+// Returns g.
+func (g *Graph) View(ids ...ID) *Graph {
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, id := range ids {
+		g.declare(id)
+	}
+	return g
+}
+
+// Entry declares all passed views as entry ones: a session that has no view
+// yet can be transited to them. Returns g.
+func (g *Graph) Entry(ids ...ID) *Graph {
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, id := range ids {
+		if g.declare(id) {
+			g.mEntries[id] = struct{}{}
+		}
+	}
+	return g
+}
+
+// Transition declares an allowed transition from view from to view to
+// triggered by event of type typ with data (see Transition for wildcards).
+// Both views are declared if they are not. Invalid View IDs are ignored.
+// Returns g.
+func (g *Graph) Transition(from, to ID, typ event.Type, data event.Data) *Graph {
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.declare(from) && g.declare(to) {
+		g.mTransitions[from] = append(g.mTransitions[from], Transition{from, to, typ, data})
+	}
+	return g
+}
+
+// Has reports whether id is declared.
+func (g *Graph) Has(id ID) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	_, found := g.mViews[id]
+	return found
+}
+
+// Views returns all declared views in the order they has been declared.
+func (g *Graph) Views() []ID {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return append(g.views[:0:0], g.views...)
+}
+
+// Transitions returns all declared transitions from view from
+// (or from all views if from is CIDNil) in the order they has been declared.
+func (g *Graph) Transitions(from ID) []Transition {
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if from != CIDNil {
+		return append([]Transition(nil), g.mTransitions[from]...)
+	}
+
+	var transitions []Transition
+	for _, id := range g.views {
+		transitions = append(transitions, g.mTransitions[id]...)
+	}
+	return transitions
+}
+
+// IsStrict reports whether illegal transitions must be rejected
+// (see ParamGraphStrict).
+func (g *Graph) IsStrict() bool {
+	return g.isStrict
+}
+
+// Check checks whether the transition from view from to view to
+// triggered by event e is allowed. Returns errors.ECOK if it is so.
+//
+// Staying in the same view is always allowed. A session without view
+// (from is CIDNil) can be transited only to entry views (see Entry).
+//
+// Returns ECUnknownView if to is not declared
+// or ECIllegalTransition if there is no such declared transition.
+// The hook (see ParamOnIllegalTransition) is called in both cases.
+func (g *Graph) Check(from, to ID, e event.Event) errors.Code {
+
+	code := g.check(from, to, e)
+	if code != errors.ECOK && g.onIllegal != nil {
+		g.onIllegal(from, to, e, code)
+	}
+	return code
+}
+
+// Unreachable returns all declared views that can not be reached
+// from entry views (see Entry) by declared transitions
+// in the order they has been declared.
+func (g *Graph) Unreachable() []ID {
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	reached := make(map[ID]struct{}, len(g.views))
+	queue := make([]ID, 0, len(g.views))
+	for _, id := range g.views {
+		if _, isEntry := g.mEntries[id]; isEntry {
+			reached[id] = struct{}{}
+			queue = append(queue, id)
+		}
+	}
+
+	for len(queue) != 0 {
+		from := queue[0]
+		queue = queue[1:]
+		for _, t := range g.mTransitions[from] {
+			if _, found := reached[t.To]; !found {
+				reached[t.To] = struct{}{}
+				queue = append(queue, t.To)
+			}
+		}
+	}
+
+	var unreachable []ID
+	for _, id := range g.views {
+		if _, found := reached[id]; !found {
+			unreachable = append(unreachable, id)
+		}
+	}
+	return unreachable
+}
+
+// check is the Check's core.
+func (g *Graph) check(from, to ID, e event.Event) errors.Code {
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	if _, found := g.mViews[to]; !found {
+		return ECUnknownView
+	}
+
+	if from == to {
+		return errors.ECOK
+	}
+
+	if from == CIDNil {
+		if _, isEntry := g.mEntries[to]; isEntry {
+			return errors.ECOK
+		}
+		return ECIllegalTransition
+	}
+
+	for _, t := range g.mTransitions[from] {
+		if t.To == to &&
+			(t.Type == event.CTypeInvalid || t.Type == e.Type) &&
+			(t.Data == event.CDataNil || t.Data == e.Data) {
+			return errors.ECOK
+		}
+	}
+
+	return ECIllegalTransition
+}
+
+// declare declares id if it is valid and is not declared yet.
+// Reports whether id is valid.
+// g.mu must be locked.
+func (g *Graph) declare(id ID) bool {
+
+	if !id.IsValid() {
+		return false
+	}
+
+	if _, found := g.mViews[id]; !found {
+		g.mViews[id] = struct{}{}
+		g.views = append(g.views, id)
+	}
+	return true
+}
+
+// MakeGraph is the Graph constructor.
+//
+// Allocates memory for internal parts and applies all params
+// (see ParamGraphStrict, ParamOnIllegalTransition).
+func MakeGraph(params ...interface{}) *Graph {
+
+	g := &Graph{
+		mViews:       make(map[ID]struct{}),
+		mEntries:     make(map[ID]struct{}),
+		mTransitions: make(map[ID][]Transition),
+	}
+
+	for _, p := range params {
+		if p, ok := p.(graphParam); ok && p != nil {
+			p(g)
+		}
+	}
+
+	return g
+}
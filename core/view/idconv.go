@@ -6,6 +6,9 @@
 package view
 
 import (
+	"hash/fnv"
+	"sync"
+
 	"github.com/qioalice/devola/core/errors"
 )
 
@@ -15,8 +18,13 @@ import (
 // Works using a two maps:
 // A view from ID to encoded value (IDEnc) and vice-versa.
 // Encode, Decode methods using these maps.
+//
+// IDConv is safe for concurrent use.
 type IDConv struct {
 
+	// Protects all fields below (params are set once by constructor).
+	mu sync.RWMutex
+
 	// When some new View ID registers, the entry of this link
 	// View ID -> View ID encoded places here.
 	mEncodeStorage map[ID]IDEnc
@@ -25,32 +33,107 @@ type IDConv struct {
 	// View ID encoded -> View ID places here.
 	mDecodeStorage map[IDEnc]ID
 
+	// All registered View IDs in the order they has been registered.
+	ids []ID
+
+	// Renamed View IDs: old View ID -> new View ID (see Alias).
+	mAliases map[ID]ID
+
+	// Registered View ID templates in the order they has been registered
+	// (see ID.IsTemplate).
+	templates []*template
+
 	// Generator for encoded View ID.
 	// Increases by one for each new View ID by Encode method.
+	// Not used if isHashed.
 	encodedIDGenerator IDEnc
+
+	// Generate encoded View IDs by hashing View IDs (see ParamHashEncoding).
+	isHashed bool
+
+	// Register unknown View IDs by Encode (see ParamAutoRegister).
+	isAutoRegister bool
+
+	// Called when Encode registers a new View ID (see ParamOnAutoRegister).
+	onAutoRegister func(id ID, idenc IDEnc)
 }
 
-// Encode tries to encode passed View ID.
-// Also registers passed View ID if it is not. Returns an encoded ID.
+// Encode tries to encode passed View ID. Returns an encoded ID.
+//
+// If passed View ID is not registered, it is registered if auto registration
+// is enabled (it's so by default, see ParamAutoRegister)
+// or CIDEncNil is returned otherwise.
+// If passed View ID is an alias (see Alias), the new View ID is encoded.
+// If passed View ID is made of registered template (see ID.IsTemplate),
+// the template is encoded (see EncodeParams to encode its parameters too).
 func (idc *IDConv) Encode(id ID) IDEnc {
 
 	if !id.IsValid() {
 		return CIDEncNil
 	}
 
-	if alreadyRegistered, found := idc.mEncodeStorage[id]; found {
-		return alreadyRegistered
+	idc.mu.RLock()
+	id = idc.resolve(id)
+	idenc, found := idc.mEncodeStorage[id]
+	if !found {
+		if t, _ := idc.templateOf(id); t != nil {
+			idenc, found = idc.mEncodeStorage[t.id], true
+		}
 	}
+	idc.mu.RUnlock()
 
-	if !idc.encodedIDGenerator.IsValid() {
-		return CIDEncNil
+	if found || !idc.isAutoRegister {
+		return idenc
 	}
 
-	idc.encodedIDGenerator++
-	idc.mEncodeStorage[id] = idc.encodedIDGenerator
-	idc.mDecodeStorage[idc.encodedIDGenerator] = id
+	idenc, isNew := idc.encode(id)
+	if isNew && idc.onAutoRegister != nil {
+		idc.onAutoRegister(id, idenc)
+	}
 
-	return idc.encodedIDGenerator
+	return idenc
+}
+
+// Register registers all passed View IDs (regardless of auto registration,
+// see ParamAutoRegister). Already registered View IDs are skipped.
+//
+// Returns ECInvalidID if some View ID is invalid (nothing is registered then)
+// or ECInvalidIDEnc if there is no free encoded View IDs anymore.
+func (idc *IDConv) Register(ids ...ID) errors.Code {
+
+	for _, id := range ids {
+		if !id.IsValid() {
+			return ECInvalidID
+		}
+	}
+
+	for _, id := range ids {
+		if idenc, _ := idc.encode(id); idenc == CIDEncNil {
+			return ECInvalidIDEnc
+		}
+	}
+
+	return errors.ECOK
+}
+
+// encode returns an encoded id registering it if it is not registered yet,
+// and reports whether it has been registered.
+// id must be valid.
+func (idc *IDConv) encode(id ID) (idenc IDEnc, isNew bool) {
+
+	idc.mu.Lock()
+	defer idc.mu.Unlock()
+
+	if alreadyRegistered, found := idc.mEncodeStorage[id]; found {
+		return alreadyRegistered, false
+	}
+
+	if idenc = idc.generate(id); idenc == CIDEncNil {
+		return CIDEncNil, false
+	}
+
+	idc.register(id, idenc)
+	return idenc, true
 }
 
 // Decode tries to decode passed encoded View ID.
@@ -60,13 +143,20 @@ func (idc *IDConv) Encode(id ID) IDEnc {
 //
 // So, it will be successfully only if some View ID is already registered
 // by Encode method.
+// If decoded View ID is an alias (see Alias), the new View ID is returned.
 func (idc *IDConv) Decode(idenc IDEnc) (ID, errors.Code) {
 
 	if !idenc.IsValid() {
 		return CIDNil, ECInvalidIDEnc
 	}
 
+	idc.mu.RLock()
 	id, found := idc.mDecodeStorage[idenc]
+	if found {
+		id = idc.resolve(id)
+	}
+	idc.mu.RUnlock()
+
 	if !found {
 		return CIDNil, ECNotRegistered
 	}
@@ -78,14 +168,122 @@ func (idc *IDConv) Decode(idenc IDEnc) (ID, errors.Code) {
 	return id, errors.ECOK
 }
 
+// Has reports whether id is registered (has been encoded by Encode method),
+// it is an alias of registered View ID (see Alias)
+// or it is made of registered template (see ID.IsTemplate).
+func (idc *IDConv) Has(id ID) bool {
+
+	idc.mu.RLock()
+	defer idc.mu.RUnlock()
+
+	if id = idc.resolve(id); idc.has(id) {
+		return true
+	}
+
+	t, _ := idc.templateOf(id)
+	return t != nil
+}
+
+// has is the same as Has but idc.mu must be locked.
+func (idc *IDConv) has(id ID) bool {
+	_, found := idc.mEncodeStorage[id]
+	return found
+}
+
+// IDs returns all registered View IDs in the order they has been registered.
+// Aliases (see Alias) are not included.
+func (idc *IDConv) IDs() []ID {
+
+	idc.mu.RLock()
+	defer idc.mu.RUnlock()
+
+	ids := make([]ID, 0, len(idc.ids))
+	for _, id := range idc.ids {
+		if !idc.isAlias(id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// generate returns a new free encoded View ID for id
+// or CIDEncNil if there is no free values anymore.
+// idc.mu must be locked.
+func (idc *IDConv) generate(id ID) IDEnc {
+
+	if idc.isHashed {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(id))
+
+		idenc := cIDEncStartValue + IDEnc(h.Sum32()%uint32(cIDEncMaxValue-cIDEncStartValue))
+		for i := 0; idc.isTaken(idenc); i++ {
+			if i == int(cIDEncMaxValue-cIDEncStartValue) {
+				return CIDEncNil
+			}
+			if idenc++; idenc == cIDEncMaxValue {
+				idenc = cIDEncStartValue
+			}
+		}
+
+		return idenc
+	}
+
+	// Values that are taken by imported Mapping are skipped.
+	for {
+		if !idc.encodedIDGenerator.IsValid() {
+			return CIDEncNil
+		}
+		idc.encodedIDGenerator++
+		if !idc.isTaken(idc.encodedIDGenerator) {
+			break
+		}
+	}
+
+	if !idc.encodedIDGenerator.IsValid() {
+		return CIDEncNil
+	}
+
+	return idc.encodedIDGenerator
+}
+
+// isTaken reports whether idenc is already registered for some View ID.
+// idc.mu must be locked.
+func (idc *IDConv) isTaken(idenc IDEnc) bool {
+	_, found := idc.mDecodeStorage[idenc]
+	return found
+}
+
+// register saves a link between id and idenc.
+// idc.mu must be locked.
+func (idc *IDConv) register(id ID, idenc IDEnc) {
+	idc.mEncodeStorage[id] = idenc
+	idc.mDecodeStorage[idenc] = id
+	idc.ids = append(idc.ids, id)
+
+	if t := parseTemplate(id); t != nil {
+		idc.templates = append(idc.templates, t)
+	}
+}
+
 // MakeIDConv is the IDConv constructor.
 //
 // Allocates memory for internal parts and initializes the default state of
-// IDEnc generator.
-func MakeIDConv() *IDConv {
-	return &IDConv{
+// IDEnc generator. Then applies all params (see ParamHashEncoding,
+// ParamAutoRegister, ParamOnAutoRegister).
+func MakeIDConv(params ...interface{}) *IDConv {
+
+	idc := &IDConv{
 		mEncodeStorage:     make(map[ID]IDEnc),
 		mDecodeStorage:     make(map[IDEnc]ID),
 		encodedIDGenerator: cIDEncStartValue,
+		isAutoRegister:     true,
+	}
+
+	for _, p := range params {
+		if p, ok := p.(param); ok && p != nil {
+			p(idc)
+		}
 	}
+
+	return idc
 }
@@ -0,0 +1,197 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package view
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/qioalice/devola/core/errors"
+)
+
+// Mapping is a set of links View ID -> View ID encoded.
+//
+// Encoded View IDs are stored in sessions and inline buttons,
+// so they must not be changed after deploy. Export the Mapping of IDConv,
+// persist it (see Save) and Import it (or Verify with it) at the next start.
+type Mapping map[ID]IDEnc
+
+// Export returns the Mapping of all registered View IDs.
+func (idc *IDConv) Export() Mapping {
+
+	idc.mu.RLock()
+	defer idc.mu.RUnlock()
+
+	m := make(Mapping, len(idc.mEncodeStorage))
+	for id, idenc := range idc.mEncodeStorage {
+		m[id] = idenc
+	}
+	return m
+}
+
+// Import registers all View IDs of m with their encoded View IDs from m,
+// so Encode will return the same encoded View IDs as before persisting m.
+// View IDs that are already registered with the same encoded View IDs are skipped.
+//
+// Nothing is imported and an error code is returned if some View ID
+// or encoded View ID of m is invalid (ECInvalidID, ECInvalidIDEnc),
+// or some View ID is registered with another encoded View ID, or some
+// encoded View ID is taken by another View ID (of m itself or registered one)
+// (ECMappingConflict).
+func (idc *IDConv) Import(m Mapping) errors.Code {
+
+	idc.mu.Lock()
+	defer idc.mu.Unlock()
+
+	decoded := make(map[IDEnc]ID, len(m))
+
+	for id, idenc := range m {
+		switch {
+		case !id.IsValid():
+			return ECInvalidID
+		case !idenc.IsValid():
+			return ECInvalidIDEnc
+		}
+		if _, found := decoded[idenc]; found {
+			return ECMappingConflict
+		}
+		decoded[idenc] = id
+		if registered, found := idc.mEncodeStorage[id]; found && registered != idenc {
+			return ECMappingConflict
+		}
+		if registered, found := idc.mDecodeStorage[idenc]; found && registered != id {
+			return ECMappingConflict
+		}
+	}
+
+	// Sorting makes registration order (see IDs) deterministic.
+	for _, id := range m.sortedIDs() {
+		if !idc.has(id) {
+			idc.register(id, m[id])
+		}
+	}
+
+	return errors.ECOK
+}
+
+// Diff returns all View IDs of persisted that are registered now
+// with another encoded View IDs (sorted).
+func (idc *IDConv) Diff(persisted Mapping) []ID {
+
+	idc.mu.RLock()
+	defer idc.mu.RUnlock()
+
+	var changed []ID
+	for _, id := range persisted.sortedIDs() {
+		if idenc, found := idc.mEncodeStorage[id]; found && idenc != persisted[id] {
+			changed = append(changed, id)
+		}
+	}
+
+	return changed
+}
+
+// Verify is the startup check: returns ECMappingChanged and changed View IDs
+// if some View ID of persisted is registered now with another encoded View ID
+// (see Diff). The application must refuse to start in that case, because
+// sessions and inline buttons with old encoded View IDs can't be resolved.
+//
+// Returns errors.ECOK if nothing is changed.
+// Call it after all View IDs are registered.
+func (idc *IDConv) Verify(persisted Mapping) ([]ID, errors.Code) {
+	if changed := idc.Diff(persisted); len(changed) != 0 {
+		return changed, ECMappingChanged
+	}
+	return nil, errors.ECOK
+}
+
+// Save writes m to the file by path as JSON.
+//
+// The file is replaced atomically: m is written to the temporary file
+// which then is renamed to path, so the file by path always contains
+// either the old Mapping or the new one, even if the process crashes.
+func (m Mapping) Save(path string) error {
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	return syncDir(filepath.Dir(path))
+}
+
+// LoadMapping reads the Mapping from the JSON file by path.
+// Returns an empty Mapping and nil error if there is no such file
+// (the first start).
+func LoadMapping(path string) (Mapping, error) {
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Mapping{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var m Mapping
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// syncDir flushes the directory by path to the disk,
+// so the renaming of file in it is persisted.
+func syncDir(path string) error {
+
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+
+	err = dir.Sync()
+	if closeErr := dir.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// sortedIDs returns all View IDs of m in sorted order.
+func (m Mapping) sortedIDs() []ID {
+
+	ids := make([]ID, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -0,0 +1,122 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package view
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/qioalice/devola/core/errors"
+)
+
+func TestImport(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		registered []ID
+		m          Mapping
+		want       errors.Code
+	}{
+		{"empty", nil, Mapping{}, errors.ECOK},
+		{"new views", nil, Mapping{"main": 200, "catalog": 201}, errors.ECOK},
+		{"same as registered", []ID{"main"}, Mapping{"main": 101}, errors.ECOK},
+		{"registered with another", []ID{"main"}, Mapping{"main": 200}, ECMappingConflict},
+		{"taken by registered", []ID{"main"}, Mapping{"catalog": 101}, ECMappingConflict},
+		{"taken within mapping", nil, Mapping{"main": 200, "catalog": 200}, ECMappingConflict},
+		{"invalid View ID", nil, Mapping{"": 200}, ECInvalidID},
+		{"invalid encoded View ID", nil, Mapping{"main": 1}, ECInvalidIDEnc},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			idc := MakeIDConv()
+			if code := idc.Register(tt.registered...); code != errors.ECOK {
+				t.Fatalf("Register: unexpected error code %v", code)
+			}
+			before := idc.Export()
+
+			if code := idc.Import(tt.m); code != tt.want {
+				t.Fatalf("Import: got error code %v, want %v", code, tt.want)
+			}
+
+			if tt.want != errors.ECOK {
+				if after := idc.Export(); !reflect.DeepEqual(after, before) {
+					t.Fatalf("failed Import changed mapping: %v, want %v", after, before)
+				}
+				return
+			}
+
+			for id, idenc := range tt.m {
+				if got := idc.Encode(id); got != idenc {
+					t.Fatalf("Encode(%q): got %d, want %d", id, got, idenc)
+				}
+				if got, code := idc.Decode(idenc); got != id || code != errors.ECOK {
+					t.Fatalf("Decode(%d): got %q, %v, want %q", idenc, got, code, id)
+				}
+			}
+		})
+	}
+}
+
+func TestDiffAndVerify(t *testing.T) {
+
+	idc := MakeIDConv()
+	if code := idc.Import(Mapping{"main": 200, "catalog": 201}); code != errors.ECOK {
+		t.Fatalf("Import: unexpected error code %v", code)
+	}
+
+	persisted := idc.Export()
+	if changed, code := idc.Verify(persisted); changed != nil || code != errors.ECOK {
+		t.Fatalf("Verify of the same mapping: got %v, %v", changed, code)
+	}
+
+	// Not registered views and the views registered later are not changed ones.
+	persisted["unknown"] = 300
+	_ = idc.Register("cart")
+	if changed := idc.Diff(persisted); changed != nil {
+		t.Fatalf("Diff: got %v, want nothing", changed)
+	}
+
+	persisted["main"], persisted["catalog"] = 202, 203
+	want := []ID{"catalog", "main"}
+
+	if changed := idc.Diff(persisted); !reflect.DeepEqual(changed, want) {
+		t.Fatalf("Diff: got %v, want %v", changed, want)
+	}
+	if changed, code := idc.Verify(persisted); !reflect.DeepEqual(changed, want) || code != ECMappingChanged {
+		t.Fatalf("Verify: got %v, %v, want %v, ECMappingChanged", changed, code, want)
+	}
+}
+
+func TestMappingSaveLoad(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "views.json")
+
+	m, err := LoadMapping(path)
+	if err != nil || len(m) != 0 {
+		t.Fatalf("LoadMapping of missing file: got %v, %v, want empty mapping", m, err)
+	}
+
+	for _, m := range []Mapping{{"main": 200}, {"main": 200, "catalog": 201}} {
+		if err := m.Save(path); err != nil {
+			t.Fatalf("Save: unexpected error: %v", err)
+		}
+		loaded, err := LoadMapping(path)
+		if err != nil {
+			t.Fatalf("LoadMapping: unexpected error: %v", err)
+		}
+		if !reflect.DeepEqual(loaded, m) {
+			t.Fatalf("LoadMapping: got %v, want %v", loaded, m)
+		}
+	}
+
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatalf("temporary file is left after Save: %v", err)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package view
+
+import (
+	"github.com/qioalice/devola/core/errors"
+	"github.com/qioalice/devola/core/event"
+)
+
+// param is an alias to function that takes an IDConv object and changes
+// its behaviour.
+// It uses as parameters for IDConv constructor.
+type param func(idc *IDConv)
+
+// ParamHashEncoding enables (or disables) the deterministic encoding of IDConv.
+//
+// By default encoded View IDs are generated by incrementing a counter,
+// so they depend on the order View IDs are registered in.
+// With hash encoding the encoded View ID is a hash of View ID (FNV-1a),
+// so it is the same across restarts regardless registration order.
+// Hash collisions are resolved by taking the next free value
+// (use Import with persisted Mapping to keep them stable too).
+func ParamHashEncoding(enable bool) param {
+	return func(idc *IDConv) { idc.isHashed = enable }
+}
+
+// ParamAutoRegister enables (or disables) the auto registration of View IDs.
+//
+// If it's enabled (by default), Encode registers passed View ID if it is not
+// registered yet. Otherwise Encode returns CIDEncNil for unknown View IDs,
+// and all View IDs must be registered explicitly using Register or Import.
+func ParamAutoRegister(enable bool) param {
+	return func(idc *IDConv) { idc.isAutoRegister = enable }
+}
+
+// ParamOnAutoRegister sets the hook that is called each time Encode
+// registers a new View ID (auto registration must be enabled).
+//
+// Registration of View IDs at runtime (from handlers) usually means
+// a typo in View ID, so it's a good place to log it.
+// The hook is called outside of IDConv's lock, but can be called
+// concurrently from different goroutines.
+func ParamOnAutoRegister(hook func(id ID, idenc IDEnc)) param {
+	return func(idc *IDConv) { idc.onAutoRegister = hook }
+}
+
+// graphParam is an alias to function that takes a Graph object and changes
+// its behaviour.
+// It uses as parameters for Graph constructor.
+type graphParam func(g *Graph)
+
+// ParamGraphStrict enables (or disables) the rejection of illegal transitions.
+//
+// By default illegal transitions are only reported (see ParamOnIllegalTransition),
+// but applied anyway. In strict mode they are not applied.
+func ParamGraphStrict(enable bool) graphParam {
+	return func(g *Graph) { g.isStrict = enable }
+}
+
+// ParamOnIllegalTransition sets the hook that is called each time
+// Graph.Check finds an illegal transition (with its error code),
+// so it's a good place to log it.
+// The hook can be called concurrently from different goroutines.
+func ParamOnIllegalTransition(hook func(from, to ID, e event.Event, code errors.Code)) graphParam {
+	return func(g *Graph) { g.onIllegal = hook }
+}
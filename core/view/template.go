@@ -0,0 +1,285 @@
+// Copyright © 2019. All rights reserved.
+// Author: Alice Qio.
+// Contacts: <qioalice@gmail.com>.
+// License: https://opensource.org/licenses/MIT
+
+package view
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/qioalice/devola/core/errors"
+)
+
+// Params are the parsed parameters of View ID made of template
+// (see ID.IsTemplate): parameter name -> value.
+type Params map[string]string
+
+// Get returns the value of parameter name or empty string if there is no such.
+func (p Params) Get(name string) string {
+	return p[name]
+}
+
+// Int returns the value of integer parameter name.
+// Returns false if there is no such parameter or it's not an integer.
+func (p Params) Int(name string) (int64, bool) {
+	n, err := strconv.ParseInt(p[name], 10, 64)
+	return n, err == nil
+}
+
+// template is the parsed View ID template like "order/:id:int/edit".
+type template struct {
+	id       ID
+	segments []segment
+}
+
+// segment is one "/" separated part of template: a literal
+// or a parameter (with its kind).
+type segment struct {
+	literal string
+	param   string
+	isInt   bool
+}
+
+// Predefined constants.
+const (
+
+	// The separator of View ID segments.
+	cIDSeparator = "/"
+
+	// The prefix of parameter segment of View ID template.
+	cIDParamPrefix = ":"
+
+	// The suffix of parameter name of integer parameter.
+	cIDParamInt = ":int"
+)
+
+// IsTemplate reports whether id is a View ID template: has at least one
+// "/" separated segment that is a parameter.
+//
+// Parameter segment is ":name" (any non-empty value without "/")
+// or ":name:int" (integer value). For example: "order/:id:int/edit".
+func (id ID) IsTemplate() bool {
+	return id.IsValid() && parseTemplate(id) != nil
+}
+
+// Fill returns the View ID made of template id with args as values of its
+// parameters (in the order they are declared in template).
+// Returns CIDNil if id is not a template, the number of args is not
+// the same as the number of parameters or some of args is not a valid value.
+//
+//	ID("order/:id:int/edit").Fill(42) == "order/42/edit"
+func (id ID) Fill(args ...interface{}) ID {
+
+	t := parseTemplate(id)
+	if t == nil {
+		return CIDNil
+	}
+
+	values := make([]string, len(args))
+	for i, arg := range args {
+		values[i] = fmt.Sprint(arg)
+	}
+
+	filled, _ := t.fill(values)
+	return filled
+}
+
+// match returns the parameters of id if it's made of t or nil otherwise.
+func (t *template) match(id ID) Params {
+
+	parts := strings.Split(string(id), cIDSeparator)
+	if len(parts) != len(t.segments) {
+		return nil
+	}
+
+	params := make(Params)
+	for i, s := range t.segments {
+		if s.param == "" {
+			if parts[i] != s.literal {
+				return nil
+			}
+			continue
+		}
+		if !s.isValid(parts[i]) {
+			return nil
+		}
+		params[s.param] = parts[i]
+	}
+
+	return params
+}
+
+// fill returns the View ID made of t with values of its parameters.
+// Returns ECBadParams if the number of values is not the same as the number
+// of parameters or some value is not valid.
+func (t *template) fill(values []string) (ID, errors.Code) {
+
+	parts := make([]string, len(t.segments))
+	for i, s := range t.segments {
+		if s.param == "" {
+			parts[i] = s.literal
+			continue
+		}
+		if len(values) == 0 || !s.isValid(values[0]) {
+			return CIDNil, ECBadParams
+		}
+		parts[i], values = values[0], values[1:]
+	}
+
+	if len(values) != 0 {
+		return CIDNil, ECBadParams
+	}
+
+	return ID(strings.Join(parts, cIDSeparator)), errors.ECOK
+}
+
+// values returns the values of t's parameters of params
+// in the order they are declared in t.
+func (t *template) values(params Params) []string {
+	var values []string
+	for _, s := range t.segments {
+		if s.param != "" {
+			values = append(values, params[s.param])
+		}
+	}
+	return values
+}
+
+// isValid reports whether value can be the value of parameter s.
+func (s *segment) isValid(value string) bool {
+
+	if value == "" || strings.Contains(value, cIDSeparator) {
+		return false
+	}
+
+	if s.isInt {
+		_, err := strconv.ParseInt(value, 10, 64)
+		return err == nil
+	}
+
+	return true
+}
+
+// parseTemplate returns the parsed template id or nil if id is not a template.
+func parseTemplate(id ID) *template {
+
+	t := &template{id: id}
+	hasParams := false
+
+	for _, part := range strings.Split(string(id), cIDSeparator) {
+		if !strings.HasPrefix(part, cIDParamPrefix) {
+			t.segments = append(t.segments, segment{literal: part})
+			continue
+		}
+
+		s := segment{param: part[len(cIDParamPrefix):]}
+		if strings.HasSuffix(s.param, cIDParamInt) {
+			s.param, s.isInt = s.param[:len(s.param)-len(cIDParamInt)], true
+		}
+		if s.param == "" {
+			return nil
+		}
+
+		t.segments = append(t.segments, s)
+		hasParams = true
+	}
+
+	if !hasParams {
+		return nil
+	}
+
+	return t
+}
+
+// Template returns the registered View ID template id is made of
+// or id itself if there is no such template.
+func (idc *IDConv) Template(id ID) ID {
+
+	idc.mu.RLock()
+	defer idc.mu.RUnlock()
+
+	if t, _ := idc.templateOf(id); t != nil {
+		return t.id
+	}
+	return id
+}
+
+// Params returns the registered View ID template id is made of and
+// the parsed parameters of id.
+// Returns CIDNil and nil if there is no such template.
+func (idc *IDConv) Params(id ID) (ID, Params) {
+
+	idc.mu.RLock()
+	defer idc.mu.RUnlock()
+
+	if t, params := idc.templateOf(id); t != nil {
+		return t.id, params
+	}
+	return CIDNil, nil
+}
+
+// EncodeParams is the same as Encode but also returns the compact
+// representation of id's parameters (if id is made of registered template),
+// so id can be restored by DecodeParams.
+func (idc *IDConv) EncodeParams(id ID) (IDEnc, string) {
+
+	idenc := idc.Encode(id)
+	if idenc == CIDEncNil {
+		return CIDEncNil, ""
+	}
+
+	idc.mu.RLock()
+	defer idc.mu.RUnlock()
+
+	if t, params := idc.templateOf(idc.resolve(id)); t != nil {
+		return idenc, strings.Join(t.values(params), cIDSeparator)
+	}
+	return idenc, ""
+}
+
+// DecodeParams is the same as Decode but if encoded View ID is a template,
+// its parameters are filled by the values from packed (see EncodeParams).
+//
+// Returns ECBadParams if packed doesn't fit the template.
+func (idc *IDConv) DecodeParams(idenc IDEnc, packed string) (ID, errors.Code) {
+
+	id, code := idc.Decode(idenc)
+	if code != errors.ECOK {
+		return CIDNil, code
+	}
+
+	t := parseTemplate(id)
+	if t == nil {
+		if packed != "" {
+			return CIDNil, ECBadParams
+		}
+		return id, errors.ECOK
+	}
+
+	var values []string
+	if packed != "" {
+		values = strings.Split(packed, cIDSeparator)
+	}
+
+	return t.fill(values)
+}
+
+// templateOf returns the first registered template id is made of
+// and the parameters of id, or nil if there is no such template.
+// idc.mu must be locked.
+func (idc *IDConv) templateOf(id ID) (*template, Params) {
+
+	if !strings.Contains(string(id), cIDSeparator) {
+		return nil, nil
+	}
+
+	for _, t := range idc.templates {
+		if params := t.match(id); params != nil {
+			return t, params
+		}
+	}
+	return nil, nil
+}
@@ -15,6 +15,10 @@ type Handler func(ctx unsafe.Pointer)
 //
 type Middleware func(ctx unsafe.Pointer) (isAllowed bool)
 
+// Around is a middleware that wraps the rest of the chain (next middlewares
+// and handlers) and can run code after them.
+type Around func(ctx unsafe.Pointer, next func())
+
 //
 type OnSuccessFinisher func(ctx, o unsafe.Pointer)
 
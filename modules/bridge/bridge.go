@@ -10,6 +10,8 @@ import (
 
 	"go.uber.org/zap"
 
+	"github.com/qioalice/devola/core/chat"
+	"github.com/qioalice/devola/core/command"
 	"github.com/qioalice/devola/core/logger"
 )
 
@@ -43,6 +45,17 @@ type Bridge struct {
 	// 		// because of that when a negative decreasing counter will reach its max,
 	// 		// we also
 	SendInfOverflow func(ctx unsafe.Pointer, err error, config interface{})
+
+	// CtxChat returns the chat the event of ctx is occurred in and the role
+	// of its sender. Passed to Registrator.Dispatch as registrator.ChatOf,
+	// which fills them to the ctx.BaseCtx, so predicates of routes can be checked.
+	CtxChat func(ctx unsafe.Pointer) (idt chat.IDT, role chat.Role)
+
+	// SetCommands pushes command menu to the backend (like Telegram's
+	// setMyCommands method) for the chat of ctx or for all chats if ctx is nil.
+	// lang is the language commands are translated to (empty for default one).
+	// Can be nil if backend doesn't support command menus.
+	SetCommands func(ctx unsafe.Pointer, commands command.Commands, lang string) error
 }
 
 //
@@ -71,3 +84,13 @@ func (b *Bridge) RecoverPanicOf(ctx unsafe.Pointer, fTyp Kind, fPtr unsafe.Point
 		zap.Any("add_info", addInfo),
 	)
 }
+
+// PushCommands pushes commands to the backend using SetCommands
+// (see SetCommands for arguments).
+// Does nothing and returns nil if backend doesn't support command menus.
+func (b *Bridge) PushCommands(ctx unsafe.Pointer, commands command.Commands, lang string) error {
+	if b.SetCommands == nil {
+		return nil
+	}
+	return b.SetCommands(ctx, commands, lang)
+}
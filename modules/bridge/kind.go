@@ -17,6 +17,8 @@ const (
 	KindOnSuccessFinisher
 
 	KindOnErrorFinisher
+
+	KindAround
 )
 
 //